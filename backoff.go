@@ -0,0 +1,30 @@
+package snowflake
+
+import (
+	"runtime"
+	"time"
+)
+
+// Backoff 等待时钟前进时(序列号耗尽等待下一毫秒、容忍范围内的时钟回拨)每轮检查时钟前的退避策略
+// sinceMilli 为开始等待时的毫秒时间，实现可据此随等待时长调整退避方式；每次调用后都会重新读取时钟
+type Backoff interface {
+	Wait(sinceMilli int64)
+}
+
+// SpinBackoff 不让出 CPU 的纯自旋，延迟最低，但等待期间会占满一个核
+type SpinBackoff struct{}
+
+// Wait 实现 Backoff
+func (SpinBackoff) Wait(int64) {}
+
+// GoschedBackoff 通过 runtime.Gosched 让出 CPU 后再检查时钟，为默认策略
+type GoschedBackoff struct{}
+
+// Wait 实现 Backoff
+func (GoschedBackoff) Wait(int64) { runtime.Gosched() }
+
+// SleepBackoff 每轮休眠指定时长，CPU 占用最低，但唤醒粒度受调度器影响，可能多等待超过一毫秒
+type SleepBackoff time.Duration
+
+// Wait 实现 Backoff
+func (b SleepBackoff) Wait(int64) { time.Sleep(time.Duration(b)) }
@@ -0,0 +1,45 @@
+//go:build unix
+
+package snowflake
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+// 进程累计占用的 CPU 时间(用户态与内核态之和)
+func cpuTime(b *testing.B) time.Duration {
+	b.Helper()
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		b.Fatal(err)
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
+
+// 每次迭代用完一毫秒的序列号并等待下一毫秒，对比各退避策略等待期间的 CPU 占用(cpu-ns/op)与耗时(ns/op)
+func BenchmarkBackoffWait(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		backoff Backoff
+	}{
+		{"spin", SpinBackoff{}},
+		{"gosched", GoschedBackoff{}},
+		{"sleep", SleepBackoff(100 * time.Microsecond)},
+	} {
+		b.Run(bc.name, func(b *testing.B) {
+			g := newBenchGenerator(b, WithBackoff(bc.backoff))
+			n := int(g.layout.maxSequenceID) + 1
+			start := cpuTime(b)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.GenerateN(n); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.StopTimer()
+			b.ReportMetric(float64(cpuTime(b)-start)/float64(b.N), "cpu-ns/op")
+		})
+	}
+}
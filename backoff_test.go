@@ -0,0 +1,33 @@
+package snowflake
+
+import "testing"
+
+// 记录每次等待的开始毫秒，等待两次后推进时钟
+type recordBackoff struct {
+	clock *manualClock
+	since []int64
+}
+
+func (b *recordBackoff) Wait(sinceMilli int64) {
+	b.since = append(b.since, sinceMilli)
+	if len(b.since)%2 == 0 {
+		b.clock.Add(1)
+	}
+}
+
+func TestCustomBackoff(t *testing.T) {
+	start := int64(epoch + 1000)
+	clock := newManualClock(start)
+	backoff := &recordBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithBackoff(backoff))
+	ids, err := g.GenerateN(int(g.MaxSequenceID()) + 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backoff.since) != 2 || backoff.since[0] != start || backoff.since[1] != start {
+		t.Fatalf("got waits since %v, want two waits since %d", backoff.since, start)
+	}
+	if ts, _, _, seq := g.Decompose(ids[len(ids)-1]); ts != start+1 || seq != 0 {
+		t.Fatalf("got last ID at (%d, %d), want (%d, 0)", ts, seq, start+1)
+	}
+}
@@ -0,0 +1,104 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// base62 字符表按 ASCII 顺序排列，保证相同长度的编码字符串按字典序排序与数值顺序一致
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+const maxBase62Len = 11 // 64 位无符号整数的 base62 编码最多 11 位
+
+var ErrInvaildBase62 = errors.New("IDGenerator: input invaild base62 string")
+
+// base62 字符到数值的映射，非法字符为 -1
+var base62Index = func() (index [256]int8) {
+	for i := range index {
+		index[i] = -1
+	}
+	for i := 0; i < len(base62Alphabet); i++ {
+		index[base62Alphabet[i]] = int8(i)
+	}
+	return
+}()
+
+// EncodeBase62 将 ID 编码为 base62 字符串，用于缩短 ID 在 URL 等场景下的长度
+// 编码不补齐长度，只有长度相同的字符串之间字典序才与数值顺序一致，长度更短的编码对应更小的数值
+// 符号位被置位的 ID(如 GenerateWithFlag 生成的负数 ID)按其 64 位无符号值编码，但超出 int64 范围，DecodeBase62 会拒绝，
+// 这类 ID 应使用十进制形式
+func EncodeBase62(id int64) string {
+	u := uint64(id)
+	if u == 0 {
+		return base62Alphabet[:1]
+	}
+	var buf [maxBase62Len]byte
+	i := len(buf)
+	for u > 0 {
+		i--
+		buf[i] = base62Alphabet[u%62]
+		u /= 62
+	}
+	return string(buf[i:])
+}
+
+// DecodeBase62 将 EncodeBase62 生成的字符串还原为 ID
+// 字符串为空、含有非法字符或数值超出 int64 范围时返回 ErrInvaildBase62
+func DecodeBase62(s string) (int64, error) {
+	if s == "" {
+		return 0, ErrInvaildBase62
+	}
+	var u uint64
+	for i := 0; i < len(s); i++ {
+		d := base62Index[s[i]]
+		if d < 0 {
+			return 0, ErrInvaildBase62
+		}
+		// 乘 62 再加 d 之前检查是否会溢出 64 位
+		if u > (^uint64(0)-uint64(d))/62 {
+			return 0, ErrInvaildBase62
+		}
+		u = u*62 + uint64(d)
+	}
+	if u > math.MaxInt64 {
+		return 0, ErrInvaildBase62
+	}
+	return int64(u), nil
+}
+
+var ErrAmbiguousID = errors.New("IDGenerator: input ambiguous ID, can be read as both decimal and base62")
+
+// ParseID 自动识别十进制或 base62 形式的 ID 字符串并解析
+// 以 '-' 开头或长度超过 base62 最大长度 11 的纯数字串按十进制解析，含字母的串按 base62 解析；
+// 长度不超过 11 且按两种方式解析结果不同的纯数字串无法区分，返回 ErrAmbiguousID，其余非法输入返回 ErrInvaildID
+// 正常生成的 ID 十进制形式至少有 12 位，不会出现歧义
+func ParseID(s string) (int64, error) {
+	if s == "" {
+		return 0, ErrInvaildID
+	}
+	digits := true
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			digits = false
+			break
+		}
+	}
+	if s[0] == '-' || digits && len(s) > maxBase62Len {
+		id, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return 0, ErrInvaildID
+		}
+		return id, nil
+	}
+	id, err := DecodeBase62(s)
+	if err != nil {
+		return 0, ErrInvaildID
+	}
+	if digits {
+		if dec, err := strconv.ParseInt(s, 10, 64); err != nil || dec != id {
+			return 0, ErrAmbiguousID
+		}
+	}
+	return id, nil
+}
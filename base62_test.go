@@ -0,0 +1,89 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"testing"
+)
+
+func TestBase62RoundTrip(t *testing.T) {
+	ids := []int64{0, 1, 61, 62, 3843, 3844, math.MaxInt32, 1 << 40, Compose(epoch+1000, 3, 17, 42), math.MaxInt64}
+	for _, id := range ids {
+		s := EncodeBase62(id)
+		got, err := DecodeBase62(s)
+		if err != nil {
+			t.Fatalf("DecodeBase62(%q) for %d: %v", s, id, err)
+		}
+		if got != id {
+			t.Fatalf("DecodeBase62(EncodeBase62(%d)) = %d", id, got)
+		}
+	}
+}
+
+func TestBase62Order(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids, err := g.GenerateN(1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	encoded := make([]string, len(ids))
+	for i, id := range ids {
+		encoded[i] = EncodeBase62(id)
+	}
+	// 同一时期生成的 ID 编码长度相同，字典序与数值顺序一致
+	if !sort.StringsAreSorted(encoded) {
+		t.Fatal("base62 encodings are not in generation order")
+	}
+}
+
+func TestDecodeBase62Invaild(t *testing.T) {
+	inputs := []string{
+		"",
+		"abc-",
+		"你好",
+		"AzL8n0Y58m8", // 2^63，超出 int64
+		"LygHa16AHYF", // 2^64-1
+		"LygHa16AHYG", // 超出 uint64
+		"000000000000LygHa16AHYG",
+	}
+	for _, s := range inputs {
+		if _, err := DecodeBase62(s); !errors.Is(err, ErrInvaildBase62) {
+			t.Errorf("DecodeBase62(%q) got %v, want ErrInvaildBase62", s, err)
+		}
+	}
+}
+
+func TestParseID(t *testing.T) {
+	// 运行一年后生成的 ID，十进制形式超过 11 位
+	id := Compose(epoch+365*24*3600*1000, 3, 17, 42)
+	for _, tc := range []struct {
+		in   string
+		want int64
+		err  error
+	}{
+		{strconv.FormatInt(id, 10), id, nil},
+		{EncodeBase62(id), id, nil},
+		{"-42", -42, nil},
+		{"7", 7, nil},
+		{"zz", 61*62 + 61, nil},
+		{"10", 0, ErrAmbiguousID},
+		{"", 0, ErrInvaildID},
+		{"-", 0, ErrInvaildID},
+		{"12.5", 0, ErrInvaildID},
+		{"abc!", 0, ErrInvaildID},
+		{"99999999999999999999", 0, ErrInvaildID},
+	} {
+		got, err := ParseID(tc.in)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("ParseID(%q): got error %v, want %v", tc.in, err, tc.err)
+		}
+		if err == nil && got != tc.want {
+			t.Fatalf("ParseID(%q) = %d, want %d", tc.in, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package snowflake
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Clock 时间源，返回当前的毫秒时间戳
+// 默认使用系统时钟，测试时可以注入自定义实现来模拟时钟回拨、序列号耗尽等场景
+type Clock interface {
+	NowMilli() int64
+}
+
+// 基于系统时钟的默认时间源
+type systemClock struct{}
+
+func (systemClock) NowMilli() int64 {
+	return time.Now().UnixMilli()
+}
+
+// 始终返回固定时间的时间源
+type fixedClock int64
+
+func (c fixedClock) NowMilli() int64 {
+	return int64(c)
+}
+
+const driftThreshold = time.Millisecond // 墙上时钟与单调时钟的偏差变化超过该值时触发回调
+
+// 检测系统墙上时钟相对单调时钟漂移的时间源包装，用于观察 NTP 等对系统时钟的调整，时间仍取自被包装的时间源
+// 记录构造时的 time.Now() 作为基准(含单调时钟读数)，之后每次取时间时比较两者相对基准走过的时长
+// 取时间时通常持有生成器的锁，因此只累计检测到的漂移，由 report 在锁外调用回调
+type driftClock struct {
+	clock     Clock
+	base      time.Time
+	callback  func(delta time.Duration)
+	lastDrift atomic.Int64 // 上一次检测到变化时的偏差，纳秒
+	pending   atomic.Int64 // 已检测到但尚未回调的漂移，纳秒
+}
+
+func (c *driftClock) NowMilli() int64 {
+	t := time.Now()
+	// t.Sub 使用单调时钟计算，Round(0) 去掉单调时钟读数后得到墙上时钟的差值
+	drift := t.Round(0).Sub(c.base.Round(0)) - t.Sub(c.base)
+	last := time.Duration(c.lastDrift.Load())
+	if d := drift - last; d > driftThreshold || d < -driftThreshold {
+		if c.lastDrift.CompareAndSwap(int64(last), int64(drift)) {
+			c.pending.Add(int64(d))
+		}
+	}
+	return c.clock.NowMilli()
+}
+
+// 调用回调报告自上次报告以来累计的漂移，调用方不能持有生成器的锁
+func (c *driftClock) report() {
+	if d := c.pending.Swap(0); d != 0 {
+		c.callback(time.Duration(d))
+	}
+}
+
+// 复制一个包装同一时间源、回调相同、以当前时间为新基准的 driftClock，漂移状态不与原时间源共享
+func (c *driftClock) clone() *driftClock {
+	return &driftClock{clock: c.clock, base: time.Now(), callback: c.callback}
+}
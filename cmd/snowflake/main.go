@@ -0,0 +1,58 @@
+// snowflake 是生成与解析雪花 ID 的命令行工具
+//
+//	snowflake gen [-idc 0] [-machine 0] [-n 1]
+//	snowflake explain <id>...
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	snowflake "github.com/leantli/classic_snowflake"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	switch os.Args[1] {
+	case "gen":
+		fs := flag.NewFlagSet("gen", flag.ExitOnError)
+		idcID := fs.Int64("idc", 0, "IDC 号")
+		machineID := fs.Int64("machine", 0, "机器号")
+		n := fs.Int("n", 1, "生成的 ID 个数")
+		fs.Parse(os.Args[2:])
+		for i := 0; i < *n; i++ {
+			id, err := snowflake.GenerateOne(*idcID, *machineID)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Println(id)
+		}
+	case "explain":
+		if len(os.Args) < 3 {
+			usage()
+		}
+		for _, arg := range os.Args[2:] {
+			id, err := strconv.ParseInt(arg, 10, 64)
+			if err != nil {
+				fatal(err)
+			}
+			fmt.Println(snowflake.Explain(id))
+		}
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: snowflake gen [-idc 0] [-machine 0] [-n 1] | snowflake explain <id>...")
+	os.Exit(2)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, err)
+	os.Exit(1)
+}
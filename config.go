@@ -0,0 +1,106 @@
+package snowflake
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+)
+
+// Config 可序列化为 JSON 的生成器配置，包括 epoch、IDC 号、机器号和 bit 布局，不包括 lastMilli 等运行状态
+// 时间源、持久化、日志等无法序列化的选项不在其中，导入时需另行通过 opts 设置
+type Config struct {
+	Epoch         int64   `json:"epoch"`
+	RegionID      int64   `json:"region_id,omitempty"`
+	IDCID         int64   `json:"idc_id"`
+	MachineID     int64   `json:"machine_id"`
+	TimestampBits int     `json:"timestamp_bits"`
+	IDCBits       int     `json:"idc_bits"`
+	MachineBits   int     `json:"machine_bits"`
+	SequenceBits  int     `json:"sequence_bits"`
+	FieldOrder    []Field `json:"field_order,omitempty"` // 为空表示默认顺序，见 WithFieldOrder
+	ChecksumBit   bool    `json:"checksum_bit,omitempty"`
+	RandomBits    int     `json:"random_bits,omitempty"`
+	RegionBits    int     `json:"region_bits,omitempty"`
+}
+
+// Config 返回本 IDGenerator 的配置
+func (g *IDGenerator) Config() Config {
+	c := Config{
+		Epoch:       g.epoch,
+		RegionID:    g.regionID,
+		IDCID:       g.IDCID,
+		MachineID:   g.machineID,
+		FieldOrder:  append([]Field(nil), g.layout.order...),
+		ChecksumBit: g.layout.checksum,
+		RandomBits:  g.layout.randomBits,
+		RegionBits:  g.layout.regionBits,
+	}
+	c.TimestampBits, c.IDCBits, c.MachineBits, c.SequenceBits = g.layout.bits()
+	return c
+}
+
+// ConfigJSON 将本 IDGenerator 的配置序列化为 JSON，可通过 NewIDGeneratorFromConfigJSON 在其他服务中重建相同配置的生成器
+func (g *IDGenerator) ConfigJSON() ([]byte, error) {
+	return json.Marshal(g.Config())
+}
+
+// NewIDGeneratorFromConfig 按配置构造生成器，校验规则与 NewIDGeneratorWithEpoch 相同，opts 在配置之后应用
+// 时间戳位数不大于 0 时无法生成任何 ID，返回 ErrInvaildLayout
+func NewIDGeneratorFromConfig(c Config, opts ...Option) (*IDGenerator, error) {
+	if c.TimestampBits <= 0 {
+		return nil, ErrInvaildLayout
+	}
+	configOpts := []Option{
+		WithTimestampBits(c.TimestampBits),
+		WithIDCBits(c.IDCBits),
+		WithMachineBits(c.MachineBits),
+		WithSequenceBits(c.SequenceBits),
+		WithFieldOrder(c.FieldOrder),
+		WithRandomBits(c.RandomBits),
+		WithRegionBits(c.RegionBits),
+	}
+	if c.ChecksumBit {
+		configOpts = append(configOpts, WithChecksumBit())
+	}
+	return newIDGeneratorWithRegion(c.RegionID, c.IDCID, c.MachineID, c.Epoch, append(configOpts, opts...))
+}
+
+// NewIDGeneratorFromConfigJSON 解析 ConfigJSON 输出的 JSON 并构造生成器，JSON 中缺少的 epoch 与各字段位数使用默认值
+// 注意：重建的生成器与导出配置的生成器使用相同的 IDC 号与机器号，两者不能同时生成 ID，多个服务共享配置时应分别修改 IDC 号或机器号
+func NewIDGeneratorFromConfigJSON(data []byte, opts ...Option) (*IDGenerator, error) {
+	c := Config{Epoch: epoch}
+	c.TimestampBits, c.IDCBits, c.MachineBits, c.SequenceBits = defaultLayout.bits()
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, err
+	}
+	return NewIDGeneratorFromConfig(c, opts...)
+}
+
+var fieldNames = [fieldCount]string{"timestamp", "idc", "machine", "sequence"}
+
+// String 返回字段名，即 timestamp、idc、machine、sequence
+func (f Field) String() string {
+	if f < 0 || f >= fieldCount {
+		return "Field(" + strconv.Itoa(int(f)) + ")"
+	}
+	return fieldNames[f]
+}
+
+// MarshalText 实现 encoding.TextMarshaler，输出字段名
+func (f Field) MarshalText() ([]byte, error) {
+	if f < 0 || f >= fieldCount {
+		return nil, ErrInvaildFieldOrder
+	}
+	return []byte(fieldNames[f]), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，解析字段名，不区分大小写
+func (f *Field) UnmarshalText(text []byte) error {
+	for i, name := range fieldNames {
+		if strings.EqualFold(name, string(text)) {
+			*f = Field(i)
+			return nil
+		}
+	}
+	return ErrInvaildFieldOrder
+}
@@ -0,0 +1,128 @@
+package snowflake
+
+import "time"
+
+// Decompose 将 Generate 生成的 ID 反解为毫秒时间戳、IDC 号、机器号和序列号
+// 返回的时间戳已加回 epoch，是真实的 Unix 毫秒时间戳，可直接用于 time.UnixMilli
+// 正常生成的 ID 符号位恒为 0，若传入的 ID 符号位被置位(负数)，反解时会忽略符号位，只解析低 63 位
+func Decompose(id int64) (timestampMilli, idcID, machineID, sequenceID int64) {
+	timestampMilli, idcID, machineID, sequenceID = defaultLayout.decompose(id)
+	timestampMilli += epoch
+	return
+}
+
+// DecomposeUint64 同 Decompose，用于 GenerateUint64 返回的 uint64 形式，最高位同样被忽略
+func DecomposeUint64(id uint64) (timestampMilli, idcID, machineID, sequenceID int64) {
+	return Decompose(int64(id))
+}
+
+// Compose 是 Decompose 的逆操作，按默认 epoch 和 bit 布局将各字段拼接为 ID
+// 各字段超出位数的部分会被截断，因此对任意 id 都有 Compose(Decompose(id)) == id 去除符号位后的值
+func Compose(timestampMilli, idcID, machineID, sequenceID int64) int64 {
+	return defaultLayout.composeMasked(timestampMilli-epoch, idcID, machineID, sequenceID)
+}
+
+// NextID 返回同一 IDC 号、机器号下按顺序紧随 id 之后的 ID，用于构造分页游标等范围查询的边界
+// 序列号未到最大值时序列号加 1，否则进入下一毫秒且序列号为 0；按默认 bit 布局计算，符号位会被忽略，
+// 时间戳与序列号均已是最大值时返回 id 去除符号位后的值本身
+// 返回的 ID 只是合成的边界值，并不一定真实生成过
+func NextID(id int64) int64 {
+	timestamp, idcID, machineID, sequenceID := defaultLayout.decompose(id)
+	switch {
+	case sequenceID < defaultLayout.maxSequenceID:
+		sequenceID++
+	case timestamp < defaultLayout.maxTimestamp:
+		timestamp, sequenceID = timestamp+1, 0
+	}
+	return defaultLayout.compose(timestamp, idcID, machineID, sequenceID)
+}
+
+// PrevID 是 NextID 的逆操作，返回同一 IDC 号、机器号下按顺序紧邻 id 之前的 ID
+// 序列号大于 0 时序列号减 1，否则回到上一毫秒且序列号为最大值；时间戳与序列号均为 0 时返回 id 去除符号位后的值本身
+func PrevID(id int64) int64 {
+	timestamp, idcID, machineID, sequenceID := defaultLayout.decompose(id)
+	switch {
+	case sequenceID > 0:
+		sequenceID--
+	case timestamp > 0:
+		timestamp, sequenceID = timestamp-1, defaultLayout.maxSequenceID
+	}
+	return defaultLayout.compose(timestamp, idcID, machineID, sequenceID)
+}
+
+// ReEpoch 将以 oldEpoch 为开始时间生成的 ID 换算为以 newEpoch 为开始时间的 ID，生成时间、IDC 号、机器号、序列号以及符号位保持不变，
+// 用于迁移 epoch 而无需重新生成 ID；按默认 bit 布局计算
+// 换算后的时间早于 newEpoch 时返回 ErrInvaildTime，超出时间戳位数时返回 ErrTimestampOverflow
+func ReEpoch(id, oldEpoch, newEpoch int64) (int64, error) {
+	timestamp, idcID, machineID, sequenceID := defaultLayout.decompose(id)
+	reEpoched, err := defaultLayout.composeChecked(timestamp+oldEpoch-newEpoch, 0, idcID, machineID, sequenceID)
+	if err != nil {
+		return -1, err
+	}
+	return reEpoched | id&flagBit, nil
+}
+
+// TimestampOf 返回 ID 的生成时间，按默认 epoch 和 bit 布局解析
+func TimestampOf(id int64) time.Time {
+	timestampMilli, _, _, _ := Decompose(id)
+	return time.UnixMilli(timestampMilli)
+}
+
+// FlagOf 返回 ID 最高位的业务标记，即 GenerateWithFlag 传入的 flag
+func FlagOf(id int64) bool {
+	return id&flagBit != 0
+}
+
+// Parts ID 反解后的各字段
+type Parts struct {
+	TimestampMilli int64 // Unix 毫秒时间戳，已加回 epoch
+	IDCID          int64 // IDC 号
+	MachineID      int64 // 机器号
+	SequenceID     int64 // 序列号
+}
+
+// DecomposeAll 批量反解 ID，语义同 Decompose，结果切片一次性分配，适用于离线分析大量 ID
+func DecomposeAll(ids []int64) []Parts {
+	parts := make([]Parts, len(ids))
+	for i, id := range ids {
+		p := &parts[i]
+		p.TimestampMilli, p.IDCID, p.MachineID, p.SequenceID = Decompose(id)
+	}
+	return parts
+}
+
+// CompareIDs 依次按时间戳、IDC 号、机器号、序列号比较两个 ID，a 小于、等于、大于 b 时分别返回 -1、0、1
+// 各字段均相同时按标记位(见 FlagOf)比较，未标记的 ID 更小，因此不同的 ID 不会比较为相等；
+// 标记位只在最后比较，对 GenerateWithFlag 生成的负数 ID 同样能得到与生成时间一致的顺序，可用于 sort.Slice
+func CompareIDs(a, b int64) int {
+	at, ai, am, as := Decompose(a)
+	bt, bi, bm, bs := Decompose(b)
+	switch {
+	case at != bt:
+		return compareInt64(at, bt)
+	case ai != bi:
+		return compareInt64(ai, bi)
+	case am != bm:
+		return compareInt64(am, bm)
+	case as != bs:
+		return compareInt64(as, bs)
+	case FlagOf(a) != FlagOf(b):
+		if FlagOf(a) {
+			return 1
+		}
+		return -1
+	default:
+		return 0
+	}
+}
+
+func compareInt64(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,293 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestDecompose(t *testing.T) {
+	g, err := NewIDGenerator(3, 17)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now().UnixMilli()
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now().UnixMilli()
+	timestampMilli, idcID, machineID, sequenceID := Decompose(id)
+	if idcID != 3 || machineID != 17 {
+		t.Fatalf("Decompose(%d) got IDC %d machine %d, want 3 17", id, idcID, machineID)
+	}
+	if timestampMilli < before || timestampMilli > after {
+		t.Fatalf("Decompose(%d) got timestamp %d, want in [%d, %d]", id, timestampMilli, before, after)
+	}
+	if sequenceID != 0 {
+		t.Fatalf("Decompose(%d) got sequence %d, want 0", id, sequenceID)
+	}
+}
+
+func TestTimestampOf(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := time.Now()
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	after := time.Now()
+	// ID 只精确到毫秒，生成时间向下取整后不早于 before 所在的毫秒
+	got := TimestampOf(id)
+	if got.Before(before.Truncate(time.Millisecond)) || got.After(after) {
+		t.Fatalf("TimestampOf(%d) = %v, want within [%v, %v]", id, got, before, after)
+	}
+	if d := got.Sub(before); d > time.Millisecond || d < -time.Millisecond {
+		t.Fatalf("TimestampOf(%d) is %v away from the Generate call", id, d)
+	}
+}
+
+func TestGenerateWithFlag(t *testing.T) {
+	g, err := NewIDGenerator(3, 17, WithSignedMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	plain, err := g.GenerateWithFlag(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	flagged, err := g.GenerateWithFlag(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if plain < 0 || FlagOf(plain) {
+		t.Fatalf("unflagged ID %d is negative or flagged", plain)
+	}
+	if flagged >= 0 || !FlagOf(flagged) {
+		t.Fatalf("flagged ID %d is not negative or not flagged", flagged)
+	}
+	pt, pi, pm, _ := Decompose(plain)
+	ft, fi, fm, fs := Decompose(flagged)
+	if fi != 3 || fm != 17 || fi != pi || fm != pm {
+		t.Fatalf("flagged ID decomposed to IDC %d machine %d", fi, fm)
+	}
+	if ft < pt || ft-pt > 1 {
+		t.Fatalf("flagged timestamp %d, unflagged %d", ft, pt)
+	}
+	if !TimestampOf(flagged).Equal(time.UnixMilli(ft)) {
+		t.Fatalf("TimestampOf(%d) = %v, want %v", flagged, TimestampOf(flagged), time.UnixMilli(ft))
+	}
+	// 去掉标记位后即为普通 ID
+	if Compose(ft, fi, fm, fs) != flagged&^flagBit {
+		t.Fatalf("Compose of flagged parts = %d, want %d", Compose(ft, fi, fm, fs), flagged&^flagBit)
+	}
+}
+
+func benchIDs(b *testing.B, n int) []int64 {
+	b.Helper()
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		b.Fatal(err)
+	}
+	ids, err := g.GenerateN(n)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return ids
+}
+
+// 与 BenchmarkDecomposeAll 对照：逐个调用 Decompose 并追加到结果切片
+func BenchmarkDecomposeLoop(b *testing.B) {
+	ids := benchIDs(b, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var parts []Parts
+		for _, id := range ids {
+			var p Parts
+			p.TimestampMilli, p.IDCID, p.MachineID, p.SequenceID = Decompose(id)
+			parts = append(parts, p)
+		}
+	}
+}
+
+func BenchmarkDecomposeAll(b *testing.B) {
+	ids := benchIDs(b, 10000)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		DecomposeAll(ids)
+	}
+}
+
+func TestCompareIDs(t *testing.T) {
+	early := Compose(epoch+1000, 1, 1, 5)
+	late := Compose(epoch+1001, 0, 0, 0)
+	tests := []struct {
+		a, b int64
+		want int
+	}{
+		{early, late, -1},
+		{late, early, 1},
+		{early, early, 0},
+		// 带标记位的 ID 是负数，按 int64 比较会排在所有普通 ID 之前
+		{late | flagBit, early, 1},
+		{early | flagBit, late, -1},
+		{early | flagBit, late | flagBit, -1},
+		// 各字段相同时按标记位区分，不同的 ID 不会相等
+		{early, early | flagBit, -1},
+		{early | flagBit, early, 1},
+		{Compose(epoch+1000, 1, 2, 0), Compose(epoch+1000, 2, 1, 0), -1},
+		{Compose(epoch+1000, 1, 2, 0), Compose(epoch+1000, 1, 1, 9), 1},
+	}
+	for _, tt := range tests {
+		if got := CompareIDs(tt.a, tt.b); got != tt.want {
+			t.Errorf("CompareIDs(%d, %d) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+
+	g, err := NewIDGenerator(1, 1, WithSignedMode())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ids := make([]int64, 0, 100)
+	for i := 0; i < cap(ids); i++ {
+		id, err := g.GenerateWithFlag(i%2 == 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+	sorted := append([]int64(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	sort.Slice(sorted, func(i, j int) bool { return CompareIDs(sorted[i], sorted[j]) < 0 })
+	for i := range ids {
+		if sorted[i] != ids[i] {
+			t.Fatalf("sorted[%d] = %d, want generation order %d", i, sorted[i], ids[i])
+		}
+	}
+}
+
+func FuzzDecompose(f *testing.F) {
+	for _, id := range []int64{0, 1, -1, maxID, flagBit, Compose(epoch+1000, 3, 17, 42)} {
+		f.Add(id)
+	}
+	f.Fuzz(func(t *testing.T, id int64) {
+		timestampMilli, idcID, machineID, sequenceID := Decompose(id)
+		if idcID < 0 || idcID > MaxIDCID() || machineID < 0 || machineID > MaxMachineID() ||
+			sequenceID < 0 || sequenceID > MaxSequenceID() || timestampMilli < epoch {
+			t.Fatalf("Decompose(%d) = %d %d %d %d out of range", id, timestampMilli, idcID, machineID, sequenceID)
+		}
+		if got := Compose(timestampMilli, idcID, machineID, sequenceID); got != id&maxID {
+			t.Fatalf("Compose(Decompose(%d)) = %d, want %d", id, got, id&maxID)
+		}
+	})
+}
+
+func TestNextPrevID(t *testing.T) {
+	l := defaultLayout
+	maxTs, maxSeq := l.maxTimestamp, l.maxSequenceID
+	for _, tc := range []struct {
+		name     string
+		id, next int64
+	}{
+		{"sequence", l.compose(1000, 3, 17, 5), l.compose(1000, 3, 17, 6)},
+		{"next millisecond", l.compose(1000, 3, 17, maxSeq), l.compose(1001, 3, 17, 0)},
+		{"first", l.compose(0, 3, 17, 0), l.compose(0, 3, 17, 1)},
+		{"last", l.compose(maxTs, 3, 17, maxSeq-1), l.compose(maxTs, 3, 17, maxSeq)},
+	} {
+		if got := NextID(tc.id); got != tc.next {
+			t.Fatalf("%s: NextID(%d) = %d, want %d", tc.name, tc.id, got, tc.next)
+		}
+		if got := PrevID(tc.next); got != tc.id {
+			t.Fatalf("%s: PrevID(%d) = %d, want %d", tc.name, tc.next, got, tc.id)
+		}
+		if tc.next <= tc.id {
+			t.Fatalf("%s: next %d not greater than %d", tc.name, tc.next, tc.id)
+		}
+	}
+
+	// 两端的边界返回自身
+	if id := l.compose(maxTs, 3, 17, maxSeq); NextID(id) != id {
+		t.Fatalf("NextID at the maximum: got %d, want %d", NextID(id), id)
+	}
+	if id := l.compose(0, 3, 17, 0); PrevID(id) != id {
+		t.Fatalf("PrevID at the minimum: got %d, want %d", PrevID(id), id)
+	}
+	// 符号位被忽略
+	if id := l.compose(1000, 3, 17, 5); NextID(id|flagBit) != l.compose(1000, 3, 17, 6) {
+		t.Fatalf("NextID of a flagged ID: got %d", NextID(id|flagBit))
+	}
+}
+
+func TestReEpoch(t *testing.T) {
+	newEpoch := int64(epoch + 24*3600*1000)
+	id := Compose(newEpoch+5000, 3, 17, 42)
+	for _, flagged := range []int64{0, flagBit} {
+		reEpoched, err := ReEpoch(id|flagged, epoch, newEpoch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts, idcID, machineID, seq := defaultLayout.decompose(reEpoched)
+		if ts != 5000 || idcID != 3 || machineID != 17 || seq != 42 || reEpoched&flagBit != flagged {
+			t.Fatalf("got (%d, %d, %d, %d) flag %v, want (5000, 3, 17, 42)", ts, idcID, machineID, seq, reEpoched&flagBit != 0)
+		}
+		back, err := ReEpoch(reEpoched, newEpoch, epoch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if back != id|flagged {
+			t.Fatalf("round trip: got %d, want %d", back, id|flagged)
+		}
+	}
+
+	// 生成时间早于新 epoch
+	if _, err := ReEpoch(Compose(epoch+1000, 3, 17, 42), epoch, newEpoch); !errors.Is(err, ErrInvaildTime) {
+		t.Fatalf("got %v, want ErrInvaildTime", err)
+	}
+	// 换算后超出 41 位时间戳
+	last := defaultLayout.compose(defaultLayout.maxTimestamp, 3, 17, 42)
+	if _, err := ReEpoch(last, epoch, epoch-1); !errors.Is(err, ErrTimestampOverflow) {
+		t.Fatalf("got %v, want ErrTimestampOverflow", err)
+	}
+}
+
+func TestUint64Lossless(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	for i := 0; i < 10; i++ {
+		u, err := g.GenerateUint64()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := g.Last()
+		if u != uint64(id) || int64(u) != id {
+			t.Fatalf("got %d, want %d", u, id)
+		}
+		ts, idcID, machineID, seq := DecomposeUint64(u)
+		if ts != clock.NowMilli() || idcID != 1 || machineID != 1 || seq != int64(i) {
+			t.Fatalf("DecomposeUint64 got (%d, %d, %d, %d)", ts, idcID, machineID, seq)
+		}
+	}
+
+	// 带标记的 ID 在 uint64 形式下不小于 1<<63，转换回 int64 不丢失任何位
+	for _, id := range []int64{0, 1, math.MaxInt64, -1, math.MinInt64, Compose(epoch+1000, 3, 17, 42) | flagBit} {
+		u := uint64(id)
+		if int64(u) != id {
+			t.Fatalf("%d does not round-trip through uint64", id)
+		}
+		if (u >= 1<<63) != (id < 0) {
+			t.Fatalf("%d: uint64 form %d disagrees on the top bit", id, u)
+		}
+		a0, a1, a2, a3 := Decompose(id)
+		b0, b1, b2, b3 := DecomposeUint64(u)
+		if a0 != b0 || a1 != b1 || a2 != b2 || a3 != b3 {
+			t.Fatalf("%d: Decompose and DecomposeUint64 disagree", id)
+		}
+	}
+}
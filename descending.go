@@ -0,0 +1,39 @@
+package snowflake
+
+// DescendingGenerator 生成按时间倒序排列的 ID：ID 为 maxID - 正常 ID，越晚生成的 ID 数值越小，
+// 适用于按主键正序扫描即可读到最新数据的 KV 存储
+// maxID 为当前 bit 布局下 ID 的最大值，默认布局下即 2^63-1，因此 ID 仍为非负数且位数不变
+// 代价：ID 不能再用包级函数 Decompose、TimestampOf 或 IDGenerator 的方法直接反解，需使用本类型的 Decompose 或 Ascending；
+// 开启 WithChecksumBit 时，倒序 ID 需先经 Ascending 还原再用 VerifyChecksum 校验；与正序 ID 混存时无法区分两者
+type DescendingGenerator struct {
+	g    *IDGenerator
+	mask int64 // 当前 bit 布局下 ID 的最大值
+}
+
+// NewDescendingGenerator 生成一个倒序 ID 生成器，参数与 NewIDGenerator 相同
+func NewDescendingGenerator(idcID, machineID int64, opts ...Option) (*DescendingGenerator, error) {
+	g, err := NewIDGenerator(idcID, machineID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &DescendingGenerator{g: g, mask: maxID >> (63 - g.layout.totalBits)}, nil
+}
+
+// Generate 生成一个倒序 ID，出错时返回 -1 与底层 IDGenerator 的错误
+func (d *DescendingGenerator) Generate() (int64, error) {
+	id, err := d.g.Generate()
+	if err != nil {
+		return -1, err
+	}
+	return d.mask - id, nil
+}
+
+// Ascending 将倒序 ID 还原为正常的正序 ID，也可将正序 ID 转换为倒序 ID
+func (d *DescendingGenerator) Ascending(id int64) int64 {
+	return d.mask - id
+}
+
+// Decompose 反解倒序 ID，语义同 IDGenerator 的 Decompose
+func (d *DescendingGenerator) Decompose(id int64) (timestampMilli, idcID, machineID, sequenceID int64) {
+	return d.g.Decompose(d.Ascending(id))
+}
@@ -0,0 +1,34 @@
+package snowflake
+
+import "testing"
+
+func TestDescendingGenerator(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithTimestampBits(30)}} {
+		clock := newManualClock(int64(epoch + 1000))
+		d, err := NewDescendingGenerator(3, 17, append(opts, WithClock(clock))...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		prev := int64(-1)
+		for i := 0; i < 100; i++ {
+			if i%10 == 0 {
+				clock.Add(1)
+			}
+			id, err := d.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id < 0 || prev != -1 && id >= prev {
+				t.Fatalf("got %d after %d, want decreasing and non-negative", id, prev)
+			}
+			prev = id
+			if asc := d.Ascending(id); d.Ascending(asc) != id || asc>>d.g.layout.totalBits != 0 {
+				t.Fatalf("Ascending(%d) = %d does not round-trip within the layout", id, asc)
+			}
+			milli, idcID, machineID, seq := d.Decompose(id)
+			if milli != clock.NowMilli() || idcID != 3 || machineID != 17 || seq != int64(i%10) {
+				t.Fatalf("got (%d, %d, %d, %d), want (%d, 3, 17, %d)", milli, idcID, machineID, seq, clock.NowMilli(), i%10)
+			}
+		}
+	}
+}
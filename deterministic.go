@@ -0,0 +1,34 @@
+package snowflake
+
+import "hash/fnv"
+
+// DeterministicID 由 key 的哈希而非时钟导出 ID，相同的 key 与节点总是得到相同的 ID，用于需要幂等的数据导入
+// 对 key 做 64 位 FNV-1a 哈希，取低 53 位填入默认布局的时间戳与序列号两个字段，idcID、machineID 按默认布局截断后填入节点字段
+// 这类 ID 不按时间有序，Decompose 得到的时间戳没有意义，也可能与同一节点实时生成的 ID 冲突，应使用单独的节点号；
+// 不同 key 之间的冲突按生日问题估算，同一节点下约 1 亿个 key 时发生至少一次冲突的概率达到一半，导入量大时应在存储层做唯一约束
+func DeterministicID(key []byte, idcID, machineID int64) int64 {
+	h := fnv.New64a()
+	h.Write(key)
+	sum := int64(h.Sum64() & maxID)
+	return defaultLayout.composeMasked(sum>>sequenceIDBits, idcID, machineID, sum)
+}
+
+// NewDeterministicGenerator 生成一个完全确定的 ID 生成器，用于需要可复现 ID 的测试
+// 生成器不读取系统时钟，时间从 startMilli 开始，每毫秒的序列号用完后直接推进到下一毫秒，不做任何等待；
+// 默认布局下第 k 个(从 0 开始)ID 恒为 Compose(startMilli+k/4096, idcID, machineID, k%4096)
+// startMilli 早于 epoch 时返回 ErrInvaildTime；opts 中的时间源会被替换，不应与 WithRandomizedSequenceStart 一起使用
+func NewDeterministicGenerator(startMilli, idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	if startMilli < epoch {
+		return nil, ErrInvaildTime
+	}
+	// 底层时钟固定落后下限一毫秒，使下限始终处于生效状态，序列号耗尽时直接推进下限
+	floor := &floorClock{clock: fixedClock(startMilli - 1)}
+	floor.floor.Store(startMilli)
+	opts = append(opts[:len(opts):len(opts)], WithClock(floor))
+	g, err := NewIDGenerator(idcID, machineID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	g.floor = floor
+	return g, nil
+}
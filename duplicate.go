@@ -0,0 +1,45 @@
+package snowflake
+
+import "errors"
+
+var ErrDuplicateDetected = errors.New("IDGenerator: duplicate ID detected, generated ID was issued recently")
+
+// WithDuplicateDetection 在内存中记录最近生成的 k 个 ID，每次生成时检查新 ID 是否已经出现过，出现则返回 ErrDuplicateDetected
+// 正常情况下重复不可能发生，该选项是开发、测试环境下发现逻辑错误(如配置错误、误用 Reset)的安全网；
+// 开启后不再使用无锁快速路径，且每次生成都要维护 k 个 ID 的集合，开销较大，不应在生产环境使用
+// 只检查经由 Generate、GenerateContext、GenerateN、Reserve 等实时生成路径的 ID，Reserve 预留的每个 ID 都会记入窗口；k <= 0 时不开启
+func WithDuplicateDetection(k int) Option {
+	return func(g *IDGenerator) {
+		if k <= 0 {
+			g.duplicates = nil
+			return
+		}
+		g.duplicates = &duplicateWindow{
+			ring: make([]int64, 0, k),
+			seen: make(map[int64]struct{}, k),
+		}
+	}
+}
+
+// duplicateWindow 最近生成的 ID 的环形缓冲区及其集合，调用方需持有生成器的锁
+type duplicateWindow struct {
+	ring []int64            // 按生成顺序记录的 ID，写满后覆盖最旧的
+	next int                // 写满后下一个被覆盖的位置
+	seen map[int64]struct{} // ring 中的 ID 集合
+}
+
+// 记录 id，id 已在窗口内时返回 false
+func (w *duplicateWindow) add(id int64) bool {
+	if _, ok := w.seen[id]; ok {
+		return false
+	}
+	if len(w.ring) < cap(w.ring) {
+		w.ring = append(w.ring, id)
+	} else {
+		delete(w.seen, w.ring[w.next])
+		w.ring[w.next] = id
+		w.next = (w.next + 1) % len(w.ring)
+	}
+	w.seen[id] = struct{}{}
+	return true
+}
@@ -0,0 +1,60 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+	"testing"
+)
+
+func TestDuplicateDetectionQuiet(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithDuplicateDetection(1000), WithBackoff(&tickBackoff{clock: clock}))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 5000; j++ {
+				if _, err := g.Generate(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if _, err := g.GenerateN(10000); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestDuplicateDetection(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithDuplicateDetection(10))
+	for i := 0; i < 5; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 误用 Reset 后在同一毫秒内重新从序列号 0 开始生成
+	g.Reset()
+	if _, err := g.Generate(); !errors.Is(err, ErrDuplicateDetected) {
+		t.Fatalf("got %v, want ErrDuplicateDetected", err)
+	}
+}
+
+func TestDuplicateDetectionReserve(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithDuplicateDetection(10))
+	if _, _, err := g.Reserve(5); err != nil {
+		t.Fatal(err)
+	}
+	g.Reset()
+	// Reset 后同一毫秒内重新从序列号 0 开始，Reserve 预留过的 ID 都已记入窗口
+	if _, err := g.Generate(); !errors.Is(err, ErrDuplicateDetected) {
+		t.Fatalf("Generate got %v, want ErrDuplicateDetected", err)
+	}
+	if _, ids, err := g.Reserve(3); !errors.Is(err, ErrDuplicateDetected) || len(ids) != 0 {
+		t.Fatalf("Reserve got %d IDs, %v, want ErrDuplicateDetected", len(ids), err)
+	}
+}
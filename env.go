@@ -0,0 +1,60 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// 构造生成器时读取的环境变量
+const (
+	EnvIDCID     = "SNOWFLAKE_IDC_ID"     // IDC 号，必填
+	EnvMachineID = "SNOWFLAKE_MACHINE_ID" // 机器号，必填
+	EnvEpoch     = "SNOWFLAKE_EPOCH"      // 开始使用时间的毫秒时间戳，选填，默认 2022-11-22 00:00:00
+)
+
+var ErrMissingEnv = errors.New("IDGenerator: required environment variable not set")
+
+// NewIDGeneratorFromEnv 从环境变量 SNOWFLAKE_IDC_ID、SNOWFLAKE_MACHINE_ID 以及可选的 SNOWFLAKE_EPOCH 构造生成器
+// 变量缺失、无法解析或超出范围时返回的错误会指明具体的变量名，并可通过 errors.Is 判断为
+// ErrMissingEnv、ErrInvaildIDCID、ErrInvaildMachineID 或 ErrInvaildEpoch
+func NewIDGeneratorFromEnv(opts ...Option) (*IDGenerator, error) {
+	idcID, err := int64FromEnv(EnvIDCID, ErrInvaildIDCID)
+	if err != nil {
+		return nil, err
+	}
+	machineID, err := int64FromEnv(EnvMachineID, ErrInvaildMachineID)
+	if err != nil {
+		return nil, err
+	}
+	epochMilli := int64(epoch)
+	if v, ok := os.LookupEnv(EnvEpoch); ok && v != "" {
+		if epochMilli, err = int64FromEnv(EnvEpoch, ErrInvaildEpoch); err != nil {
+			return nil, err
+		}
+	}
+	g, err := NewIDGeneratorWithEpoch(idcID, machineID, epochMilli, opts...)
+	switch {
+	case errors.Is(err, ErrInvaildIDCID):
+		return nil, fmt.Errorf("%w: %s=%d", err, EnvIDCID, idcID)
+	case errors.Is(err, ErrInvaildMachineID):
+		return nil, fmt.Errorf("%w: %s=%d", err, EnvMachineID, machineID)
+	case errors.Is(err, ErrInvaildEpoch):
+		return nil, fmt.Errorf("%w: %s=%d", err, EnvEpoch, epochMilli)
+	}
+	return g, err
+}
+
+// 读取并解析整数环境变量，无法解析时将 invaild 包装后返回
+func int64FromEnv(name string, invaild error) (int64, error) {
+	v, ok := os.LookupEnv(name)
+	if !ok || v == "" {
+		return 0, fmt.Errorf("%w: %s", ErrMissingEnv, name)
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s=%q is not an integer", invaild, name, v)
+	}
+	return n, nil
+}
@@ -0,0 +1,52 @@
+package snowflake
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNewIDGeneratorFromEnv(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		env  map[string]string // 值为 "-" 的变量被删除
+		err  error
+		bad  string // 错误信息中应指明的变量名
+	}{
+		{"set", map[string]string{EnvIDCID: "3", EnvMachineID: "7", EnvEpoch: "-"}, nil, ""},
+		{"epoch", map[string]string{EnvIDCID: "3", EnvMachineID: "7", EnvEpoch: strconv.Itoa(epoch + 1000)}, nil, ""},
+		{"missing idc", map[string]string{EnvIDCID: "-", EnvMachineID: "7"}, ErrMissingEnv, EnvIDCID},
+		{"empty machine", map[string]string{EnvIDCID: "3", EnvMachineID: ""}, ErrMissingEnv, EnvMachineID},
+		{"idc not integer", map[string]string{EnvIDCID: "three", EnvMachineID: "7"}, ErrInvaildIDCID, EnvIDCID},
+		{"machine out of range", map[string]string{EnvIDCID: "3", EnvMachineID: "32"}, ErrInvaildMachineID, EnvMachineID},
+		{"epoch not integer", map[string]string{EnvIDCID: "3", EnvMachineID: "7", EnvEpoch: "yesterday"}, ErrInvaildEpoch, EnvEpoch},
+		{"epoch in future", map[string]string{EnvIDCID: "3", EnvMachineID: "7", EnvEpoch: "99999999999999"}, ErrInvaildEpoch, EnvEpoch},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			for name, v := range tc.env {
+				t.Setenv(name, v)
+				if v == "-" {
+					os.Unsetenv(name)
+				}
+			}
+			g, err := NewIDGeneratorFromEnv()
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), tc.bad) {
+					t.Fatalf("error %q does not name %s", err, tc.bad)
+				}
+				return
+			}
+			if g.IDCID != 3 || g.machineID != 7 {
+				t.Fatalf("got idc %d machine %d, want 3 7", g.IDCID, g.machineID)
+			}
+			if want, ok := tc.env[EnvEpoch]; ok && want != "-" && strconv.FormatInt(g.Epoch(), 10) != want {
+				t.Fatalf("got epoch %d, want %s", g.Epoch(), want)
+			}
+		})
+	}
+}
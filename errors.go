@@ -0,0 +1,68 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ClockBackError 时钟回拨错误，记录回拨前后的毫秒时间，便于判断回拨幅度
+// errors.Is(err, ErrClockBack) 对其成立
+type ClockBackError struct {
+	LastMilli int64 // 上一次生成 ID 的毫秒时间
+	NowMilli  int64 // 检测到回拨时的当前毫秒时间
+}
+
+// Delta 返回时钟回拨的毫秒数
+func (e *ClockBackError) Delta() int64 {
+	return e.LastMilli - e.NowMilli
+}
+
+func (e *ClockBackError) Error() string {
+	return fmt.Sprintf("%s: last %dms, now %dms, turn back %dms", ErrClockBack.Error(), e.LastMilli, e.NowMilli, e.Delta())
+}
+
+// Is 使 errors.Is(err, ErrClockBack) 成立
+func (e *ClockBackError) Is(target error) bool {
+	return target == ErrClockBack
+}
+
+// PanicError Stream 的后台 goroutine 中发生的 panic，由 Stream 恢复后写入 error channel
+type PanicError struct {
+	Value any    // recover 得到的值
+	Stack []byte // 发生 panic 时的调用栈
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("IDGenerator: stream panic: %v", e.Value)
+}
+
+// ValidationError NewIDGeneratorValidated 汇总的全部配置错误
+// 实现了 Unwrap() []error，Go 1.20 及以上版本的 errors.Is、errors.As 会逐个检查其中的错误；
+// 同时实现了 Is，使 errors.Is 在更早的版本中同样可用
+type ValidationError struct {
+	Errs []error // 按检查顺序排列的各项错误
+}
+
+func (e *ValidationError) Error() string {
+	msgs := make([]string, len(e.Errs))
+	for i, err := range e.Errs {
+		msgs[i] = err.Error()
+	}
+	return "IDGenerator: invaild config: " + strings.Join(msgs, "; ")
+}
+
+// Unwrap 返回汇总的全部错误
+func (e *ValidationError) Unwrap() []error {
+	return e.Errs
+}
+
+// Is 汇总的任一错误满足 errors.Is(err, target) 时返回 true
+func (e *ValidationError) Is(target error) bool {
+	for _, err := range e.Errs {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,26 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClockBackError(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock)
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-25)
+	_, err := g.Generate()
+	if !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want ErrClockBack", err)
+	}
+	var cbe *ClockBackError
+	if !errors.As(err, &cbe) {
+		t.Fatalf("got %T, want *ClockBackError", err)
+	}
+	if cbe.LastMilli != epoch+1000 || cbe.NowMilli != epoch+975 || cbe.Delta() != 25 {
+		t.Fatalf("got last %d now %d delta %d, want %d %d 25", cbe.LastMilli, cbe.NowMilli, cbe.Delta(), epoch+1000, epoch+975)
+	}
+}
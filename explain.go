@@ -0,0 +1,47 @@
+package snowflake
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// GenerateOne 和 Explain 供 cmd/snowflake 等轻量调用方使用，无需自行管理 IDGenerator
+
+var (
+	oneMutex      sync.Mutex
+	oneGenerators = map[int64]*IDGenerator{} // 按节点号(IDC 号与机器号拼接)缓存的默认配置生成器
+)
+
+// GenerateOne 使用默认配置为 (idcID, machineID) 生成一个 ID
+// 同一节点的生成器在进程内缓存复用，因此连续调用不会生成重复 ID
+func GenerateOne(idcID, machineID int64) (int64, error) {
+	if idcID > maxIDCID || idcID < 0 {
+		return -1, ErrInvaildIDCID
+	}
+	if machineID > maxMachineID || machineID < 0 {
+		return -1, ErrInvaildMachineID
+	}
+	nodeID := idcID<<machineIDBits | machineID
+	oneMutex.Lock()
+	g, ok := oneGenerators[nodeID]
+	if !ok {
+		var err error
+		if g, err = NewIDGenerator(idcID, machineID); err != nil {
+			oneMutex.Unlock()
+			return -1, err
+		}
+		oneGenerators[nodeID] = g
+	}
+	oneMutex.Unlock()
+	return g.Generate()
+}
+
+// Explain 按默认 epoch 和 bit 布局反解 ID，返回便于阅读的单行描述，时间为 UTC 的 ISO 8601 格式，例如：
+//
+//	id=4198633472 time=2022-11-21T16:00:01.001Z idc=1 machine=1 sequence=0
+func Explain(id int64) string {
+	timestampMilli, idcID, machineID, sequenceID := Decompose(id)
+	return fmt.Sprintf("id=%d time=%s idc=%d machine=%d sequence=%d", id,
+		time.UnixMilli(timestampMilli).UTC().Format("2006-01-02T15:04:05.000Z07:00"), idcID, machineID, sequenceID)
+}
@@ -0,0 +1,41 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestExplain(t *testing.T) {
+	for _, tc := range []struct {
+		id   int64
+		want string
+	}{
+		{4198633472, "id=4198633472 time=2022-11-21T16:00:01.001Z idc=1 machine=1 sequence=0"},
+		{362387890708479, "id=362387890708479 time=2022-11-22T16:00:00.005Z idc=31 machine=17 sequence=4095"},
+		{0, "id=0 time=2022-11-21T16:00:00.000Z idc=0 machine=0 sequence=0"},
+	} {
+		if got := Explain(tc.id); got != tc.want {
+			t.Fatalf("Explain(%d):\n got %s\nwant %s", tc.id, got, tc.want)
+		}
+	}
+}
+
+func TestGenerateOne(t *testing.T) {
+	a, err := GenerateOne(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := GenerateOne(2, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b <= a {
+		t.Fatalf("got %d after %d, want increasing", b, a)
+	}
+	if _, err := GenerateOne(32, 0); !errors.Is(err, ErrInvaildIDCID) {
+		t.Fatalf("got %v, want ErrInvaildIDCID", err)
+	}
+	if _, err := GenerateOne(0, -1); !errors.Is(err, ErrInvaildMachineID) {
+		t.Fatalf("got %v, want ErrInvaildMachineID", err)
+	}
+}
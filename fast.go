@@ -0,0 +1,88 @@
+package snowflake
+
+// FastGenerator 不加锁的雪花算法 ID 生成器，生成逻辑与 IDGenerator 相同
+// 非并发安全：适用于每个 goroutine 独占一个生成器的场景，省去锁和原子操作的开销；
+// 多个 goroutine 共享同一实例会生成重复 ID，go test -race 可以检测到这种误用
+// 各实例之间同样需要使用互不相同的 IDC 号/机器号组合
+type FastGenerator struct {
+	lastMilli          int64     // 上一次生成 ID 的毫秒时间
+	sequenceID         int64     // 本毫秒内的序列号
+	machineID          int64     // 本 FastGenerator 所属机器号
+	IDCID              int64     // 本 FastGenerator 所属 IDC 号
+	regionID           int64     // 本 FastGenerator 所属区域号
+	epoch              int64     // 开始使用时间，毫秒时间戳
+	clockBackTolerance int64     // 可容忍的时钟回拨毫秒数
+	clock              Clock     // 时间源
+	backoff            Backoff   // 等待时钟前进时的退避策略
+	layout             bitLayout // 各字段的 bit 布局
+}
+
+// NewFastGenerator 生成一个不加锁的 ID 生成器，参数校验与 NewIDGenerator 相同
+// opts 中只有 bit 布局(含 WithRandomBits)、时间源、时钟回拨容忍度和 WithBackoff 会生效，限流、严格校验等其余选项被忽略
+func NewFastGenerator(idcID, machineID int64, opts ...Option) (*FastGenerator, error) {
+	g, err := NewIDGenerator(idcID, machineID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	// 不支持 WithDriftCallback，直接使用被包装的时间源
+	clock := g.clock
+	if g.drift != nil {
+		clock = g.drift.clock
+	}
+	return &FastGenerator{
+		lastMilli:          -1,
+		machineID:          g.machineID,
+		IDCID:              g.IDCID,
+		regionID:           g.regionID,
+		epoch:              g.epoch,
+		clockBackTolerance: g.clockBackTolerance,
+		clock:              clock,
+		backoff:            g.backoff,
+		layout:             g.layout,
+	}, nil
+}
+
+// Generate 生成一个 ID
+func (g *FastGenerator) Generate() (int64, error) {
+	now := g.clock.NowMilli()
+	if now < g.epoch {
+		return -1, ErrClockBeforeEpoch
+	}
+	if now < g.lastMilli {
+		if g.lastMilli-now > g.clockBackTolerance {
+			return -1, &ClockBackError{LastMilli: g.lastMilli, NowMilli: now}
+		}
+		now = g.tilMilli(now, g.lastMilli)
+	}
+	if now == g.lastMilli {
+		if g.sequenceID < g.layout.maxSequenceID {
+			g.sequenceID++
+			return g.compose(now), nil
+		}
+		now = g.tilMilli(now, g.lastMilli+1)
+	}
+	if now-g.epoch > g.layout.maxTimestamp {
+		return -1, ErrTimestampOverflow
+	}
+	g.lastMilli = now
+	g.sequenceID = 0
+	return g.compose(now), nil
+}
+
+// 以当前序列号拼接 ID，开启 WithRandomBits 时填入随机部分
+func (g *FastGenerator) compose(now int64) int64 {
+	var random int64
+	if g.layout.randomBits > 0 {
+		random = cryptoRandom(g.layout.maxRandom)
+	}
+	return g.layout.composeAll(now-g.epoch, g.regionID, g.IDCID, g.machineID, g.sequenceID, random)
+}
+
+// 等待到时钟不早于 target 毫秒
+func (g *FastGenerator) tilMilli(now, target int64) int64 {
+	for since := now; now < target; {
+		g.backoff.Wait(since)
+		now = g.clock.NowMilli()
+	}
+	return now
+}
@@ -0,0 +1,53 @@
+package snowflake
+
+import "testing"
+
+func TestFastGeneratorOptions(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	backoff := &tickBackoff{clock: clock}
+	g, err := NewFastGenerator(1, 1, WithClock(clock), WithSequenceBits(6), WithRandomBits(2), WithBackoff(backoff))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var random int64
+	for i := 0; i < 32; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts, _, _, seq := g.layout.decompose(id); ts != int64(1000+i/16) || seq != int64(i%16) {
+			t.Fatalf("ID %d got timestamp %d sequence %d", i, ts, seq)
+		}
+		random |= id >> g.layout.randomShift & g.layout.maxRandom
+	}
+	// 每毫秒 16 个序列号，第 17 个 ID 需要通过注入的退避策略等待下一毫秒
+	if n := backoff.waits.Load(); n != 1 {
+		t.Fatalf("got %d backoff waits, want 1", n)
+	}
+	if random == 0 {
+		t.Fatalf("no ID carried random bits")
+	}
+}
+
+// 单 goroutine 下对照 FastGenerator 与加锁的 IDGenerator
+func BenchmarkFastGenerator(b *testing.B) {
+	b.Run("locked", func(b *testing.B) {
+		g := newBenchGenerator(b, wideSequence...)
+		for i := 0; i < b.N; i++ {
+			if _, err := g.Generate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("fast", func(b *testing.B) {
+		g, err := NewFastGenerator(1, 1, wideSequence...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for i := 0; i < b.N; i++ {
+			if _, err := g.Generate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package snowflake
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+const floorLease = 1000 // 每次持久化的下限领先当前毫秒的时长，毫秒
+
+// WithMonotonicFloor 以 path 文件持久化一个单调不减的毫秒时间下限，即使系统时钟被完全重置也不会生成重复 ID
+// 构造时读取文件中的下限(文件不存在视为没有下限)，之后生成器使用 max(系统时钟, 下限) 作为当前时间：
+// 每生成一个新毫秒的 ID 下限都会随之抬高，系统时钟落后于下限时，同一毫秒的序列号耗尽后直接将下限推进一毫秒而不是等待；
+// 下限以领先 1 秒的租约写入文件，因此重启后下限仍不早于已生成的任何 ID
+// 注意：下限生效期间 ID 中的时间戳来自下限而非真实时间，不再准确反映生成时间
+func WithMonotonicFloor(path string) Option {
+	return func(g *IDGenerator) {
+		g.floorPath = path
+	}
+}
+
+// 取 max(clock, floor) 作为当前时间的时间源
+type floorClock struct {
+	clock Clock
+	floor atomic.Int64
+}
+
+func (c *floorClock) NowMilli() int64 {
+	now := c.clock.NowMilli()
+	if floor := c.floor.Load(); now < floor {
+		return floor
+	}
+	return now
+}
+
+// 将下限抬高到不早于 milli
+func (c *floorClock) raise(milli int64) {
+	for {
+		floor := c.floor.Load()
+		if floor >= milli || c.floor.CompareAndSwap(floor, milli) {
+			return
+		}
+	}
+}
+
+// 系统时钟落后于下限时直接将下限推进到 target 并返回 true，否则返回 false 由调用方等待系统时钟
+func (c *floorClock) advance(target int64) bool {
+	if c.clock.NowMilli() >= c.floor.Load() {
+		return false
+	}
+	c.raise(target)
+	return true
+}
+
+// 读取下限文件并替换时间源，调用方需在应用完所有 Option 后调用
+func (g *IDGenerator) restoreFloor() error {
+	var floor int64
+	data, err := os.ReadFile(g.floorPath)
+	switch {
+	case err == nil:
+		if text := strings.TrimSpace(string(data)); text != "" {
+			if floor, err = strconv.ParseInt(text, 10, 64); err != nil {
+				return err
+			}
+		}
+	case !os.IsNotExist(err):
+		return err
+	}
+	g.floor = &floorClock{clock: g.clock}
+	g.floor.floor.Store(floor)
+	g.floorPersisted = floor
+	g.clock = g.floor
+	return nil
+}
+
+// 进入新毫秒 now 时抬高下限，now 追上已持久化的租约时写入新的租约，调用方需持有锁
+func (g *IDGenerator) flushFloor(now int64) error {
+	if g.floor == nil {
+		return nil
+	}
+	g.floor.raise(now)
+	if g.floorPath == "" || now < g.floorPersisted {
+		return nil
+	}
+	lease := now + floorLease
+	// 先写临时文件再重命名，避免写到一半时崩溃导致下限文件损坏
+	tmp := g.floorPath + ".tmp"
+	if err := os.WriteFile(tmp, []byte(strconv.FormatInt(lease, 10)), 0o644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, g.floorPath); err != nil {
+		return err
+	}
+	g.floorPersisted = lease
+	return nil
+}
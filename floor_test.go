@@ -0,0 +1,43 @@
+package snowflake
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestMonotonicFloorClockReset(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "floor")
+	clock := newManualClock(int64(epoch + 100000))
+	g := newTestGenerator(t, clock, WithMonotonicFloor(path))
+	var last int64
+	for i := 0; i < 10; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		last = id
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if floor, err := strconv.ParseInt(string(data), 10, 64); err != nil || floor < clock.NowMilli() {
+		t.Fatalf("got floor %q, want at least %d", data, clock.NowMilli())
+	}
+
+	// 模拟重启后系统时钟被重置到更早的时间，时钟不再前进时序列号耗尽后直接推进下限
+	clock.Set(int64(epoch + 1000))
+	g = newTestGenerator(t, clock, WithMonotonicFloor(path))
+	for i := int64(0); i <= g.MaxSequenceID()+10; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatalf("generate after clock reset: %v", err)
+		}
+		if id <= last {
+			t.Fatalf("got %d after %d, want increasing", id, last)
+		}
+		last = id
+	}
+}
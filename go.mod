@@ -1,3 +1,5 @@
-module snowflake
+module github.com/leantli/classic_snowflake
 
 go 1.19
+
+require github.com/mattn/go-sqlite3 v1.14.16
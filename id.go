@@ -0,0 +1,88 @@
+package snowflake
+
+import (
+	"database/sql/driver"
+	"encoding"
+	"errors"
+	"strconv"
+)
+
+var ErrInvaildID = errors.New("IDGenerator: input invaild ID")
+
+var (
+	_ encoding.TextMarshaler   = ID(0)
+	_ encoding.TextUnmarshaler = (*ID)(nil)
+)
+
+// ID 雪花算法生成的 ID，可由 Generate 的返回值直接转换得到
+// JSON 序列化时输出为字符串，避免 JavaScript 等只能安全表示 53 位整数的客户端丢失精度
+type ID int64
+
+// Int64 返回 ID 的 int64 形式
+func (id ID) Int64() int64 {
+	return int64(id)
+}
+
+// String 返回 ID 的十进制字符串形式
+func (id ID) String() string {
+	return strconv.FormatInt(int64(id), 10)
+}
+
+// MarshalJSON 将 ID 序列化为带引号的十进制字符串
+func (id ID) MarshalJSON() ([]byte, error) {
+	buf := make([]byte, 0, 22)
+	buf = append(buf, '"')
+	buf = strconv.AppendInt(buf, int64(id), 10)
+	return append(buf, '"'), nil
+}
+
+// UnmarshalJSON 同时兼容带引号的字符串和裸数字两种形式，null 不做任何修改
+func (id *ID) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	if len(data) >= 2 && data[0] == '"' && data[len(data)-1] == '"' {
+		data = data[1 : len(data)-1]
+	}
+	return id.parse(string(data))
+}
+
+// MarshalText 实现 encoding.TextMarshaler，输出十进制字符串，供 YAML、TOML、URL 查询参数绑定等基于文本的格式使用
+// 作为 JSON 对象的 key 时同样使用该形式
+func (id ID) MarshalText() ([]byte, error) {
+	return strconv.AppendInt(nil, int64(id), 10), nil
+}
+
+// UnmarshalText 实现 encoding.TextUnmarshaler，解析十进制字符串，非法输入返回 ErrInvaildID
+func (id *ID) UnmarshalText(text []byte) error {
+	return id.parse(string(text))
+}
+
+// Scan 实现 sql.Scanner，兼容驱动返回的 int64、[]byte 和 string，其余类型(包括 NULL)返回 ErrInvaildID
+func (id *ID) Scan(src any) error {
+	switch v := src.(type) {
+	case int64:
+		*id = ID(v)
+		return nil
+	case []byte:
+		return id.parse(string(v))
+	case string:
+		return id.parse(v)
+	default:
+		return ErrInvaildID
+	}
+}
+
+// Value 实现 driver.Valuer，以 int64 写入数据库
+func (id ID) Value() (driver.Value, error) {
+	return int64(id), nil
+}
+
+func (id *ID) parse(s string) error {
+	v, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return ErrInvaildID
+	}
+	*id = ID(v)
+	return nil
+}
@@ -0,0 +1,67 @@
+package snowflake
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestIDGenerator128(t *testing.T) {
+	customEpoch := int64(epoch + 24*3600*1000)
+	clock := newManualClock(customEpoch + 1000)
+	backoff := &tickBackoff{clock: clock}
+	g, err := NewIDGenerator128WithEpoch(40000, 9000000, customEpoch,
+		WithClock(clock), WithBackoff(backoff), WithClockBackTolerance(5*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var prev [16]byte
+	for i := int64(0); i < 3; i++ {
+		id, err := g.Generate128()
+		if err != nil {
+			t.Fatal(err)
+		}
+		milli, idcID, machineID, seq := g.Decompose(id)
+		if milli != clock.NowMilli() || idcID != 40000 || machineID != 9000000 || seq != i {
+			t.Fatalf("got (%d, %d, %d, %d), want (%d, 40000, 9000000, %d)", milli, idcID, machineID, seq, clock.NowMilli(), i)
+		}
+		if back, err := DecodeHex128(EncodeHex128(id)); err != nil || back != id {
+			t.Fatalf("hex round trip of %x: got (%x, %v)", id, back, err)
+		}
+		if bytes.Compare(id[:], prev[:]) <= 0 {
+			t.Fatalf("got %x after %x, want increasing", id, prev)
+		}
+		prev = id
+	}
+
+	// 容忍范围内的回拨通过退避策略等待时钟追上
+	clock.Add(-3)
+	if _, err := g.Generate128(); err != nil {
+		t.Fatalf("rollback within tolerance: %v", err)
+	}
+	if backoff.waits.Load() != 3 {
+		t.Fatalf("got %d waits, want 3", backoff.waits.Load())
+	}
+	clock.Add(-6)
+	if _, err := g.Generate128(); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want ErrClockBack", err)
+	}
+	clock.Set(customEpoch - 1)
+	if _, err := g.Generate128(); !errors.Is(err, ErrClockBeforeEpoch) {
+		t.Fatalf("got %v, want ErrClockBeforeEpoch", err)
+	}
+
+	if _, err := NewIDGenerator128WithEpoch(1, 1, time.Now().Add(time.Hour).UnixMilli()); !errors.Is(err, ErrInvaildEpoch) {
+		t.Fatalf("got %v, want ErrInvaildEpoch", err)
+	}
+	if _, err := NewIDGenerator128(maxIDCID128+1, 1); !errors.Is(err, ErrInvaildIDCID) {
+		t.Fatalf("got %v, want ErrInvaildIDCID", err)
+	}
+	if _, err := NewIDGenerator128(1, maxMachineID128+1); !errors.Is(err, ErrInvaildMachineID) {
+		t.Fatalf("got %v, want ErrInvaildMachineID", err)
+	}
+	if _, err := DecodeHex128("zz"); !errors.Is(err, ErrInvaildID) {
+		t.Fatalf("got %v, want ErrInvaildID", err)
+	}
+}
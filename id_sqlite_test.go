@@ -0,0 +1,41 @@
+//go:build cgo
+
+package snowflake
+
+import (
+	"database/sql"
+	"testing"
+
+	_ "github.com/mattn/go-sqlite3"
+)
+
+func TestIDSQLiteRoundTrip(t *testing.T) {
+	db, err := sql.Open("sqlite3", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	if _, err := db.Exec("CREATE TABLE items (id INTEGER PRIMARY KEY, id_text TEXT)"); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewIDGenerator(3, 17)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := ID(raw)
+	// id_text 以字符串存储，驱动读取时返回 []byte 或 string
+	if _, err := db.Exec("INSERT INTO items (id, id_text) VALUES (?, ?)", id, id.String()); err != nil {
+		t.Fatal(err)
+	}
+	var fromInt, fromText ID
+	if err := db.QueryRow("SELECT id, id_text FROM items WHERE id = ?", id).Scan(&fromInt, &fromText); err != nil {
+		t.Fatal(err)
+	}
+	if fromInt != id || fromText != id {
+		t.Fatalf("got %d and %d, want %d", fromInt, fromText, id)
+	}
+}
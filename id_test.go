@@ -0,0 +1,136 @@
+package snowflake
+
+import (
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestIDJSONRoundTrip(t *testing.T) {
+	// 超出 2^53 的 ID 作为 JSON 数字解码到 interface{} 时会变成 float64 而丢失精度
+	id := ID(math.MaxInt64 - 1)
+	data, err := json.Marshal(map[string]any{"id": id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var m map[string]any
+	if err := json.Unmarshal(data, &m); err != nil {
+		t.Fatal(err)
+	}
+	s, ok := m["id"].(string)
+	if !ok {
+		t.Fatalf("id decoded as %T, want string", m["id"])
+	}
+	if s != id.String() {
+		t.Fatalf("got %q, want %q", s, id.String())
+	}
+
+	var back struct {
+		ID ID `json:"id"`
+	}
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back.ID != id {
+		t.Fatalf("got %d, want %d", back.ID, id)
+	}
+}
+
+func TestIDUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ID
+		wantErr bool
+	}{
+		{`"9223372036854775806"`, math.MaxInt64 - 1, false},
+		{`9223372036854775806`, math.MaxInt64 - 1, false},
+		{`null`, 7, false},
+		{`"abc"`, 7, true},
+		{`1.5`, 7, true},
+	}
+	for _, tt := range tests {
+		id := ID(7)
+		err := id.UnmarshalJSON([]byte(tt.in))
+		if (err != nil) != tt.wantErr || id != tt.want {
+			t.Errorf("UnmarshalJSON(%s) = %d, %v, want %d, error %v", tt.in, id, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestIDScan(t *testing.T) {
+	tests := []struct {
+		src     any
+		want    ID
+		wantErr bool
+	}{
+		{int64(9223372036854775806), math.MaxInt64 - 1, false},
+		{[]byte("9223372036854775806"), math.MaxInt64 - 1, false},
+		{"9223372036854775806", math.MaxInt64 - 1, false},
+		{"-5", -5, false},
+		{nil, 7, true},
+		{[]byte("abc"), 7, true},
+		{"9223372036854775808", 7, true},
+		{float64(1), 7, true},
+	}
+	for _, tt := range tests {
+		id := ID(7)
+		err := id.Scan(tt.src)
+		if (err != nil) != tt.wantErr || id != tt.want {
+			t.Errorf("Scan(%#v) = %d, %v, want %d, error %v", tt.src, id, err, tt.want, tt.wantErr)
+		}
+	}
+	v, err := ID(42).Value()
+	if err != nil || v != int64(42) {
+		t.Fatalf("Value() = %#v, %v, want int64(42)", v, err)
+	}
+}
+
+func TestIDTextRoundTrip(t *testing.T) {
+	for _, id := range []ID{0, 1, 4198633472, math.MaxInt64, -1} {
+		var m encoding.TextMarshaler = id
+		text, err := m.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(text) != strconv.FormatInt(int64(id), 10) {
+			t.Fatalf("MarshalText(%d) = %q, want the decimal form", id, text)
+		}
+		var back ID
+		var u encoding.TextUnmarshaler = &back
+		if err := u.UnmarshalText(text); err != nil || back != id {
+			t.Fatalf("UnmarshalText(%q) = (%d, %v), want %d", text, back, err, id)
+		}
+	}
+	if err := new(ID).UnmarshalText([]byte("12a")); !errors.Is(err, ErrInvaildID) {
+		t.Fatalf("got %v, want ErrInvaildID", err)
+	}
+
+	// 文本格式库通过 TextMarshaler 编解码，例如 JSON 的 map key 与 XML 的属性
+	ids := map[ID]string{ID(math.MaxInt64): "max"}
+	data, err := json.Marshal(ids)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"9223372036854775807":"max"}` {
+		t.Fatalf("got %s", data)
+	}
+	back := map[ID]string{}
+	if err := json.Unmarshal(data, &back); err != nil || back[ID(math.MaxInt64)] != "max" {
+		t.Fatalf("got (%v, %v)", back, err)
+	}
+	type item struct {
+		ID ID `xml:"id,attr"`
+	}
+	data, err = xml.Marshal(item{ID: 4198633472})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var x item
+	if err := xml.Unmarshal(data, &x); err != nil || x.ID != 4198633472 {
+		t.Fatalf("XML round trip of %s: got (%d, %v)", data, x.ID, err)
+	}
+}
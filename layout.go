@@ -0,0 +1,256 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"math/bits"
+	"strconv"
+)
+
+const timestampBits = 63 - sequenceIDBits - machineIDBits - idcIDBits // 时间戳占用的 bit 位，默认 41 位
+
+var (
+	ErrInvaildLayout     = errors.New("IDGenerator: input invaild bit layout, total bits can not exceed 63")
+	ErrInvaildFieldOrder = errors.New("IDGenerator: input invaild field order, each field must appear exactly once")
+)
+
+// 默认的 bit 布局，与包级常量保持一致
+var defaultLayout = mustLayout(timestampBits, idcIDBits, machineIDBits, sequenceIDBits)
+
+// Field ID 中的字段，用于 WithFieldOrder 指定字段排列顺序
+type Field int
+
+const (
+	FieldTimestamp Field = iota // 时间戳
+	FieldIDC                    // IDC 号
+	FieldMachine                // 机器号
+	FieldSequence               // 序列号
+	fieldCount                  // 字段个数
+)
+
+// 默认的字段顺序，从高位到低位
+var defaultFieldOrder = []Field{FieldTimestamp, FieldIDC, FieldMachine, FieldSequence}
+
+// bitLayout 描述 ID 中各字段占用的 bit 位数，以及由此计算出的偏移量和最大值
+type bitLayout struct {
+	timestampBits   int     // 时间戳占用的 bit 位
+	regionBits      int     // 区域号占用的 bit 位，见 WithRegionBits
+	idcIDBits       int     // IDC 号占用的 bit 位
+	machineIDBits   int     // 机器号占用的 bit 位
+	sequenceIDBits  int     // 序列号占用的 bit 位
+	order           []Field // 字段从高位到低位的排列顺序，nil 表示默认顺序
+	checksum        bool    // 最低位是否为校验位，见 WithChecksumBit
+	randomBits      int     // 序列号字段中随机部分占用的 bit 位，见 WithRandomBits
+	randomShift     int     // 随机部分的偏移量
+	totalBits       int     // 各字段的总位数
+	sequenceIDShift int     // 序列号的偏移量，默认顺序下为 0
+	machineIDShift  int     // 机器号的偏移量
+	idcIDShift      int     // IDC 号的偏移量
+	regionShift     int     // 区域号的偏移量，区域号总是紧挨在 IDC 号之上
+	unixMilliShift  int     // 时间戳的偏移量
+	maxSequenceID   int64   // 序列号的最大值
+	maxMachineID    int64   // 机器号的最大值
+	maxIDCID        int64   // IDC 号的最大值
+	maxRegionID     int64   // 区域号的最大值
+	maxTimestamp    int64   // 时间戳(相对 epoch)的最大值
+	maxRandom       int64   // 随机部分的最大值
+}
+
+// newLayout 根据各字段的 bit 位数计算默认顺序下的布局，任一字段为负数或总位数超过 63 时返回 ErrInvaildLayout
+func newLayout(timestampBits, idcIDBits, machineIDBits, sequenceIDBits int) (bitLayout, error) {
+	l := bitLayout{
+		timestampBits:  timestampBits,
+		idcIDBits:      idcIDBits,
+		machineIDBits:  machineIDBits,
+		sequenceIDBits: sequenceIDBits,
+	}
+	return l.rebuild()
+}
+
+// 按布局中记录的各字段位数、排列顺序、校验位与随机位重新计算偏移量和最大值，用于应用修改布局的 Option 之后
+// 序列号字段从低位到高位依次为随机部分(见 WithRandomBits)和计数部分，校验位(见 WithChecksumBit)固定为 ID 的最低位
+// 开启区域号且总位数超过 63 时，超出的位数(不超过区域号位数)从时间戳中扣除，见 WithRegionBits
+// 任一字段为负数、总位数超过 63 或序列号不足以容纳校验位与随机部分时返回 ErrInvaildLayout，
+// order 中各字段不是恰好出现一次时返回 ErrInvaildFieldOrder
+func (l *bitLayout) rebuild() (bitLayout, error) {
+	timestampBits, idcIDBits, machineIDBits, sequenceIDBits := l.bits()
+	if excess := timestampBits + l.regionBits + idcIDBits + machineIDBits + sequenceIDBits - 63; l.regionBits > 0 && excess > 0 {
+		if excess > l.regionBits {
+			excess = l.regionBits
+		}
+		timestampBits -= excess
+	}
+	sequenceWidth := sequenceIDBits - l.randomBits
+	if l.checksum {
+		sequenceWidth--
+	}
+	if timestampBits < 0 || idcIDBits < 0 || machineIDBits < 0 || l.regionBits < 0 || l.randomBits < 0 || sequenceWidth < 0 ||
+		timestampBits+l.regionBits+idcIDBits+machineIDBits+sequenceIDBits > 63 {
+		return bitLayout{}, ErrInvaildLayout
+	}
+	nl := bitLayout{
+		timestampBits:  timestampBits,
+		idcIDBits:      idcIDBits,
+		machineIDBits:  machineIDBits,
+		sequenceIDBits: sequenceIDBits,
+		order:          l.order,
+		checksum:       l.checksum,
+		randomBits:     l.randomBits,
+		regionBits:     l.regionBits,
+		totalBits:      timestampBits + l.regionBits + idcIDBits + machineIDBits + sequenceIDBits,
+	}
+	order := l.order
+	if order == nil {
+		order = defaultFieldOrder
+	}
+	if len(order) != int(fieldCount) {
+		return bitLayout{}, ErrInvaildFieldOrder
+	}
+	// 从最低位的字段开始依次累加偏移量
+	var seen [fieldCount]bool
+	shift := 0
+	if l.checksum {
+		shift = 1
+	}
+	for i := len(order) - 1; i >= 0; i-- {
+		f := order[i]
+		if f < 0 || f >= fieldCount || seen[f] {
+			return bitLayout{}, ErrInvaildFieldOrder
+		}
+		seen[f] = true
+		switch f {
+		case FieldTimestamp:
+			nl.unixMilliShift, shift = shift, shift+timestampBits
+		case FieldIDC:
+			nl.idcIDShift, nl.regionShift, shift = shift, shift+idcIDBits, shift+idcIDBits+l.regionBits
+		case FieldMachine:
+			nl.machineIDShift, shift = shift, shift+machineIDBits
+		case FieldSequence:
+			nl.randomShift = shift
+			nl.sequenceIDShift, shift = shift+l.randomBits, shift+l.randomBits+sequenceWidth
+		}
+	}
+	nl.maxSequenceID = ^(-1 << sequenceWidth)
+	nl.maxMachineID = ^(-1 << machineIDBits)
+	nl.maxIDCID = ^(-1 << idcIDBits)
+	nl.maxRegionID = ^(-1 << l.regionBits)
+	nl.maxTimestamp = ^(-1 << timestampBits)
+	nl.maxRandom = ^(-1 << l.randomBits)
+	return nl, nil
+}
+
+func mustLayout(timestampBits, idcIDBits, machineIDBits, sequenceIDBits int) bitLayout {
+	l, err := newLayout(timestampBits, idcIDBits, machineIDBits, sequenceIDBits)
+	if err != nil {
+		panic(err)
+	}
+	return l
+}
+
+// 按布局将各字段拼接为 ID，区域号为 0，timestamp 为相对 epoch 的毫秒数，开启校验位时同时写入校验位
+func (l *bitLayout) compose(timestamp, idcID, machineID, sequenceID int64) int64 {
+	return l.composeAll(timestamp, 0, idcID, machineID, sequenceID, 0)
+}
+
+// 同 compose，同时写入区域号，以及序列号字段的随机部分 random，校验位在写入所有字段之后计算
+func (l *bitLayout) composeAll(timestamp, regionID, idcID, machineID, sequenceID, random int64) int64 {
+	id := timestamp<<l.unixMilliShift | regionID<<l.regionShift | idcID<<l.idcIDShift | machineID<<l.machineIDShift |
+		sequenceID<<l.sequenceIDShift | random<<l.randomShift
+	if l.checksum {
+		id |= parity(id)
+	}
+	return id
+}
+
+// 返回 id 低 63 位的奇偶校验值，1 的个数为奇数时为 1
+func parity(id int64) int64 {
+	return int64(bits.OnesCount64(uint64(id&maxID)) & 1)
+}
+
+// VerifyChecksum 校验由 WithChecksumBit 生成的 ID 的校验位，64 位中任意一位翻转都会使校验失败
+// 符号位不参与奇偶计算，被置位的负数 ID 直接视为校验失败；WithSignedMode 下带标记的 ID 需先清除符号位再校验，IsValid 会自动处理
+// 未开启校验位的生成器生成的 ID 约有一半无法通过校验
+func VerifyChecksum(id int64) bool {
+	return id >= 0 && parity(id) == 0
+}
+
+// 同 compose，但先将各字段截断到各自的位数，避免越界的字段污染相邻字段
+func (l *bitLayout) composeMasked(timestamp, idcID, machineID, sequenceID int64) int64 {
+	return l.compose(timestamp&l.maxTimestamp, idcID&l.maxIDCID, machineID&l.maxMachineID, sequenceID&l.maxSequenceID)
+}
+
+// 同 composeAll(随机部分为 0)，但先校验各字段是否在布局范围内，供调用方传入字段的接口使用，超出范围时返回对应的错误
+func (l *bitLayout) composeChecked(timestamp, regionID, idcID, machineID, sequenceID int64) (int64, error) {
+	if err := l.check(timestamp, regionID, idcID, machineID, sequenceID); err != nil {
+		return -1, err
+	}
+	return l.composeAll(timestamp, regionID, idcID, machineID, sequenceID, 0), nil
+}
+
+// 校验各字段是否在各自的位数范围内，返回的错误包装了对应字段的错误，可用 errors.Is 判断
+func (l *bitLayout) check(timestamp, regionID, idcID, machineID, sequenceID int64) error {
+	switch {
+	case timestamp < 0:
+		return fmt.Errorf("%w: timestamp %d", ErrInvaildTime, timestamp)
+	case timestamp > l.maxTimestamp:
+		return fmt.Errorf("%w: timestamp %d does not fit in %d bits", ErrTimestampOverflow, timestamp, l.timestampBits)
+	case regionID < 0 || regionID > l.maxRegionID:
+		return fmt.Errorf("%w: region ID %d does not fit in %d bits", ErrInvaildRegionID, regionID, l.regionBits)
+	case idcID < 0 || idcID > l.maxIDCID:
+		return fmt.Errorf("%w: IDC ID %d does not fit in %d bits", ErrInvaildIDCID, idcID, l.idcIDBits)
+	case machineID < 0 || machineID > l.maxMachineID:
+		return fmt.Errorf("%w: machine ID %d does not fit in %d bits", ErrInvaildMachineID, machineID, l.machineIDBits)
+	case sequenceID < 0 || sequenceID > l.maxSequenceID:
+		return fmt.Errorf("%w: sequence ID %d does not fit in %d bits", ErrInvaildSequenceID, sequenceID, l.sequenceIDBits)
+	}
+	return nil
+}
+
+// 按布局将 ID 拆分为各字段，符号位会被忽略，返回的 timestamp 为相对 epoch 的毫秒数
+func (l *bitLayout) decompose(id int64) (timestamp, idcID, machineID, sequenceID int64) {
+	id &= maxID
+	timestamp = id >> l.unixMilliShift & l.maxTimestamp
+	idcID = id >> l.idcIDShift & l.maxIDCID
+	machineID = id >> l.machineIDShift & l.maxMachineID
+	sequenceID = id >> l.sequenceIDShift & l.maxSequenceID
+	return
+}
+
+// 返回 ID 中的区域号，未开启区域号时恒为 0
+func (l *bitLayout) region(id int64) int64 {
+	return id >> l.regionShift & l.maxRegionID
+}
+
+// MaxMachineID 返回默认 bit 布局下机器号的最大值，可用于构造 IDGenerator 前校验输入
+func MaxMachineID() int64 {
+	return defaultLayout.maxMachineID
+}
+
+// MaxIDCID 返回默认 bit 布局下 IDC 号的最大值
+func MaxIDCID() int64 {
+	return defaultLayout.maxIDCID
+}
+
+// MaxSequenceID 返回默认 bit 布局下每毫秒序列号的最大值
+func MaxSequenceID() int64 {
+	return defaultLayout.maxSequenceID
+}
+
+// Layout 返回默认布局下时间戳、IDC 号、机器号、序列号各自占用的 bit 位数，即 41、5、5、12
+func Layout() (timestampBits, idcBits, machineBits, sequenceBits int) {
+	return defaultLayout.bits()
+}
+
+// DefaultEpoch 返回默认的 epoch 毫秒时间戳 1669046400000，即 2022-11-22 00:00:00(UTC+8)，包级函数 Decompose 等按此解析
+func DefaultEpoch() int64 {
+	return epoch
+}
+
+// 布局下最大 ID(各字段全为 1，不含符号位)的十进制位数
+func (l *bitLayout) decimalWidth() int {
+	return len(strconv.FormatInt(maxID>>(63-l.totalBits), 10))
+}
+
+func (l *bitLayout) bits() (timestampBits, idcBits, machineBits, sequenceBits int) {
+	return l.timestampBits, l.idcIDBits, l.machineIDBits, l.sequenceIDBits
+}
@@ -0,0 +1,176 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestComposeChecked(t *testing.T) {
+	l := defaultLayout
+	for _, tc := range []struct {
+		name                             string
+		timestamp, idcID, machineID, seq int64
+		err                              error
+	}{
+		{"valid", 1000, 3, 17, 42, nil},
+		{"max", l.maxTimestamp, l.maxIDCID, l.maxMachineID, l.maxSequenceID, nil},
+		{"negative timestamp", -1, 0, 0, 0, ErrInvaildTime},
+		{"timestamp overflow", l.maxTimestamp + 1, 0, 0, 0, ErrTimestampOverflow},
+		{"negative IDC", 1000, -1, 0, 0, ErrInvaildIDCID},
+		{"IDC overflow", 1000, l.maxIDCID + 1, 0, 0, ErrInvaildIDCID},
+		{"negative machine", 1000, 0, -1, 0, ErrInvaildMachineID},
+		{"machine overflow", 1000, 0, l.maxMachineID + 1, 0, ErrInvaildMachineID},
+		{"negative sequence", 1000, 0, 0, -1, ErrInvaildSequenceID},
+		{"sequence overflow", 1000, 0, 0, l.maxSequenceID + 1, ErrInvaildSequenceID},
+	} {
+		id, err := l.composeChecked(tc.timestamp, 0, tc.idcID, tc.machineID, tc.seq)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("%s: got %v, want %v", tc.name, err, tc.err)
+		}
+		if err != nil {
+			if id != -1 {
+				t.Fatalf("%s: got ID %d with an error, want -1", tc.name, id)
+			}
+			continue
+		}
+		timestamp, idcID, machineID, seq := l.decompose(id)
+		if timestamp != tc.timestamp || idcID != tc.idcID || machineID != tc.machineID || seq != tc.seq {
+			t.Fatalf("%s: got (%d, %d, %d, %d)", tc.name, timestamp, idcID, machineID, seq)
+		}
+	}
+	// 未开启区域号时区域号只能为 0
+	if _, err := l.composeChecked(1000, 1, 0, 0, 0); !errors.Is(err, ErrInvaildRegionID) {
+		t.Fatalf("got %v, want ErrInvaildRegionID", err)
+	}
+}
+
+func TestFieldOrder(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	for _, order := range [][]Field{
+		{FieldMachine, FieldIDC, FieldTimestamp, FieldSequence},
+		{FieldSequence, FieldTimestamp, FieldMachine, FieldIDC},
+	} {
+		g, err := NewIDGenerator(3, 17, WithClock(clock), WithFieldOrder(order))
+		if err != nil {
+			t.Fatal(err)
+		}
+		for i := int64(0); i < 3; i++ {
+			id, err := g.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if id < 0 {
+				t.Fatalf("order %v: got negative ID %d", order, id)
+			}
+			milli, idcID, machineID, seq := g.Decompose(id)
+			if milli != clock.NowMilli() || idcID != 3 || machineID != 17 || seq != i {
+				t.Fatalf("order %v: got (%d, %d, %d, %d), want (%d, 3, 17, %d)", order, milli, idcID, machineID, seq, clock.NowMilli(), i)
+			}
+			composed, err := g.Compose(milli, idcID, machineID, seq)
+			if err != nil || composed != id {
+				t.Fatalf("order %v: Compose got (%d, %v), want %d", order, composed, err, id)
+			}
+		}
+	}
+
+	for _, order := range [][]Field{
+		{FieldTimestamp, FieldIDC, FieldMachine},
+		{FieldTimestamp, FieldIDC, FieldMachine, FieldMachine},
+		{FieldTimestamp, FieldIDC, FieldMachine, FieldSequence, FieldSequence},
+	} {
+		if _, err := NewIDGenerator(1, 1, WithFieldOrder(order)); !errors.Is(err, ErrInvaildFieldOrder) {
+			t.Fatalf("order %v: got %v, want ErrInvaildFieldOrder", order, err)
+		}
+	}
+}
+
+func TestChecksumBit(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithChecksumBit())
+	if g.MaxSequenceID() != 2047 {
+		t.Fatalf("got MaxSequenceID %d, want 2047", g.MaxSequenceID())
+	}
+	ids, err := g.GenerateN(100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, id := range ids {
+		if !VerifyChecksum(id) {
+			t.Fatalf("generated ID %d fails the checksum", id)
+		}
+		if _, idcID, machineID, seq := g.Decompose(id); idcID != 1 || machineID != 1 || seq != int64(i) {
+			t.Fatalf("got (%d, %d, %d), want (1, 1, %d)", idcID, machineID, seq, i)
+		}
+		for bit := 0; bit < 64; bit++ {
+			if VerifyChecksum(id ^ int64(uint64(1)<<bit)) {
+				t.Fatalf("flipping bit %d of %d was not detected", bit, id)
+			}
+		}
+	}
+}
+
+func TestChecksumBitSigned(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithChecksumBit(), WithSignedMode())
+	id, err := g.GenerateWithFlag(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if VerifyChecksum(id) {
+		t.Fatalf("VerifyChecksum accepted the negative ID %d", id)
+	}
+	if !VerifyChecksum(id&math.MaxInt64) || !g.IsValid(id) {
+		t.Fatalf("flagged ID %d should pass once the sign bit is cleared", id)
+	}
+}
+
+func TestRegionBits(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g, err := NewIDGeneratorWithRegion(2, 3, 17, WithClock(clock), WithRegionBits(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认布局已用满 63 位，区域号的两位从时间戳中扣除
+	if ts, idc, machine, seq := g.Layout(); ts != 39 || idc != 5 || machine != 5 || seq != 12 || g.RegionBits() != 2 {
+		t.Fatalf("got layout (%d, %d, %d, %d) and %d region bits, want (39, 5, 5, 12) and 2", ts, idc, machine, seq, g.RegionBits())
+	}
+	for i := int64(0); i < 3; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id < 0 {
+			t.Fatalf("got negative ID %d", id)
+		}
+		milli, regionID, idcID, machineID, seq := g.DecomposeRegion(id)
+		if milli != clock.NowMilli() || regionID != 2 || idcID != 3 || machineID != 17 || seq != i {
+			t.Fatalf("got (%d, %d, %d, %d, %d), want (%d, 2, 3, 17, %d)", milli, regionID, idcID, machineID, seq, clock.NowMilli(), i)
+		}
+		if composed, err := g.Compose(milli, idcID, machineID, seq); err != nil || composed != id {
+			t.Fatalf("Compose got (%d, %v), want %d", composed, err, id)
+		}
+	}
+
+	// 自行让出位数时不再扣除时间戳
+	g, err = NewIDGeneratorWithRegion(1, 3, 17, WithRegionBits(2), WithSequenceBits(10))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _, seq := g.Layout(); ts != 41 || seq != 10 {
+		t.Fatalf("got timestamp %d sequence %d bits, want 41 and 10", ts, seq)
+	}
+
+	for _, tc := range []struct {
+		regionID int64
+		opts     []Option
+	}{
+		{4, []Option{WithRegionBits(2)}},
+		{-1, []Option{WithRegionBits(2)}},
+		{1, nil},
+	} {
+		if _, err := NewIDGeneratorWithRegion(tc.regionID, 3, 17, tc.opts...); !errors.Is(err, ErrInvaildRegionID) {
+			t.Fatalf("region %d: got %v, want ErrInvaildRegionID", tc.regionID, err)
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package snowflake
+
+// Logger 结构化日志接口，用于在时钟回拨、序列号耗尽等值得关注的事件发生时输出日志
+type Logger interface {
+	Log(event string, fields map[string]any)
+}
+
+// Logger 输出的事件
+const (
+	EventClockBack         = "clock_back"         // 检测到时钟回拨，fields 含 last_milli、now_milli
+	EventClockBeforeEpoch  = "clock_before_epoch" // 时钟早于 epoch，fields 含 epoch、now_milli
+	EventSequenceExhausted = "sequence_exhausted" // 同一毫秒内序列号耗尽，fields 含 milli
+	EventTimestampOverflow = "timestamp_overflow" // 时间戳超出时间戳位数，fields 含 now_milli、epoch
+)
+
+// WithLogger 设置事件日志，默认不输出；未设置时不会构造事件字段，没有额外开销
+func WithLogger(logger Logger) Option {
+	return func(g *IDGenerator) {
+		g.logger = logger
+	}
+}
@@ -0,0 +1,94 @@
+package snowflake
+
+import (
+	"sync"
+	"testing"
+)
+
+type loggedEvent struct {
+	event  string
+	fields map[string]any
+}
+
+// 记录所有事件的测试用日志
+type captureLogger struct {
+	mutex  sync.Mutex
+	events []loggedEvent
+}
+
+func (l *captureLogger) Log(event string, fields map[string]any) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.events = append(l.events, loggedEvent{event, fields})
+}
+
+// 返回最后一个事件，没有事件时 ok 为 false
+func (l *captureLogger) last() (e loggedEvent, ok bool) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if len(l.events) == 0 {
+		return e, false
+	}
+	return l.events[len(l.events)-1], true
+}
+
+func TestLoggerEvents(t *testing.T) {
+	start := int64(epoch + 1000)
+	// 10 位时间戳的 epoch，使 start 恰好是时间戳字段的最后一毫秒
+	narrowEpoch := start - 1023
+	for _, tc := range []struct {
+		event   string
+		epoch   int64
+		opts    []Option
+		trigger func(clock *manualClock, g *IDGenerator) // 生成第一个 ID 后、触发事件的 Generate 之前执行
+		fields  map[string]any
+	}{
+		{
+			EventClockBack, epoch, nil,
+			func(clock *manualClock, g *IDGenerator) { clock.Add(-10) },
+			map[string]any{"last_milli": start, "now_milli": start - 10},
+		},
+		{
+			EventClockBeforeEpoch, epoch, nil,
+			func(clock *manualClock, g *IDGenerator) { clock.Set(epoch - 1) },
+			map[string]any{"epoch": int64(epoch), "now_milli": int64(epoch - 1)},
+		},
+		{
+			EventSequenceExhausted, epoch, []Option{WithSequenceBits(1)},
+			func(clock *manualClock, g *IDGenerator) { g.Generate() },
+			map[string]any{"milli": start},
+		},
+		{
+			EventTimestampOverflow, narrowEpoch, []Option{WithTimestampBits(10)},
+			func(clock *manualClock, g *IDGenerator) { clock.Add(1) },
+			map[string]any{"now_milli": start + 1, "epoch": narrowEpoch},
+		},
+	} {
+		t.Run(tc.event, func(t *testing.T) {
+			clock := newManualClock(start)
+			logger := &captureLogger{}
+			opts := append([]Option{WithClock(clock), WithLogger(logger), WithBackoff(&tickBackoff{clock: clock})}, tc.opts...)
+			g, err := NewIDGeneratorWithEpoch(1, 1, tc.epoch, opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := g.Generate(); err != nil {
+				t.Fatal(err)
+			}
+			if e, ok := logger.last(); ok {
+				t.Fatalf("got event %s before the trigger", e.event)
+			}
+			tc.trigger(clock, g)
+			g.Generate()
+			e, ok := logger.last()
+			if !ok || e.event != tc.event {
+				t.Fatalf("got events %v, want %s", logger.events, tc.event)
+			}
+			for k, v := range tc.fields {
+				if e.fields[k] != v {
+					t.Fatalf("field %s: got %v, want %v", k, e.fields[k], v)
+				}
+			}
+		})
+	}
+}
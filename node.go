@@ -0,0 +1,152 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"math"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrNoIPv4Address = errors.New("IDGenerator: no private IPv4 address found")
+	ErrInvaildNodeID = errors.New("IDGenerator: input invaild node ID")
+	ErrNoPodOrdinal  = errors.New("IDGenerator: hostname has no numeric pod ordinal suffix")
+)
+
+// NodeIDOf 返回 ID 中 IDC 号与机器号合并而成的节点号，默认布局下为 10 位，取值范围 0 到 1023
+func NodeIDOf(id int64) int64 {
+	return id >> machineIDShift & (maxIDCID<<machineIDBits | maxMachineID)
+}
+
+// NewIDGeneratorFromNode 将 IDC 号与机器号视为一个整体的节点号来构造生成器，
+// 节点号的高 idcIDBits 位作为 IDC 号，低 machineIDBits 位作为机器号，超出范围时返回 ErrInvaildNodeID
+func NewIDGeneratorFromNode(nodeID int64, opts ...Option) (*IDGenerator, error) {
+	g, err := configure(epoch, opts)
+	if err != nil {
+		return nil, err
+	}
+	if nodeID < 0 || nodeID > g.layout.maxIDCID<<g.layout.machineIDBits|g.layout.maxMachineID {
+		return nil, ErrInvaildNodeID
+	}
+	if err := g.setNode(nodeID>>g.layout.machineIDBits, nodeID&g.layout.maxMachineID); err != nil {
+		return nil, err
+	}
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// MachineIDFromIP 取本机第一个私有(RFC 1918) IPv4 地址，将其低 machineIDBits 位作为机器号，没有私有地址时返回 ErrNoIPv4Address
+// 注意：低位相同的两台主机(例如不同网段下主机号相同)会得到相同的机器号，
+// 与 IDCIDFromIP 搭配时取的是 IP 的低 10 位，只有同一 /22 网段内的主机才能保证不冲突
+func MachineIDFromIP() (int64, error) {
+	ip, err := privateIPv4()
+	if err != nil {
+		return -1, err
+	}
+	return int64(ip) & maxMachineID, nil
+}
+
+// IDCIDFromIP 取本机第一个私有(RFC 1918) IPv4 地址，将机器号之上的 idcIDBits 位作为 IDC 号
+// 冲突风险同 MachineIDFromIP
+func IDCIDFromIP() (int64, error) {
+	ip, err := privateIPv4()
+	if err != nil {
+		return -1, err
+	}
+	return int64(ip) >> machineIDBits & maxIDCID, nil
+}
+
+// MachineIDFromHostname 对 os.Hostname() 做 FNV-1a 哈希并取低 machineIDBits 位作为机器号
+// 适用于 Kubernetes 等 hostname 稳定唯一而 IP 经常变化的环境
+// 注意：默认只有 5 位机器号即 32 个取值，按生日问题估算 7 台主机发生冲突的概率就超过 50%，
+// 规模较大时应通过 WithMachineBits 增加机器号位数，或改用显式分配的机器号
+func MachineIDFromHostname() (int64, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return -1, err
+	}
+	h := fnv.New32a()
+	h.Write([]byte(hostname))
+	return int64(h.Sum32()) & maxMachineID, nil
+}
+
+// MachineIDFromPodOrdinal 解析 hostname 末尾的数字作为 StatefulSet 的 Pod 序号，并取低 machineIDBits 位作为机器号
+// 例如 app-3、web-db-12 分别得到 3、12，hostname 为 app-3.app.default.svc 这类带域名的形式时只看第一段；
+// hostname 不以数字结尾时返回 ErrNoPodOrdinal
+// 注意：序号超过 MaxMachineID 时会被截断而与其他 Pod 冲突，副本数较多时应通过 WithMachineBits 增加机器号位数
+func MachineIDFromPodOrdinal() (int64, error) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return -1, err
+	}
+	ordinal, err := podOrdinal(hostname)
+	if err != nil {
+		return -1, err
+	}
+	return ordinal & maxMachineID, nil
+}
+
+// 解析 hostname 第一段末尾的十进制数字
+func podOrdinal(hostname string) (int64, error) {
+	name, _, _ := strings.Cut(hostname, ".")
+	i := len(name)
+	for i > 0 && name[i-1] >= '0' && name[i-1] <= '9' {
+		i--
+	}
+	if i == len(name) {
+		return -1, ErrNoPodOrdinal
+	}
+	ordinal, err := strconv.ParseInt(name[i:], 10, 64)
+	if err != nil {
+		return -1, ErrNoPodOrdinal
+	}
+	return ordinal, nil
+}
+
+// CollisionProbability 估算 nodes 个节点在 bits 位的节点号空间内随机(如按哈希)取值时至少两个节点冲突的概率
+// 使用生日问题的近似公式 1 - exp(-n(n-1) / 2^(bits+1))，nodes 较小时略低于精确值，
+// 例如默认 5 位机器号下 7 个节点约为 0.48(精确值约 0.51)；nodes 超过空间大小时必然冲突，返回 1
+func CollisionProbability(nodes int, bits int) float64 {
+	if nodes <= 1 {
+		return 0
+	}
+	if bits < 0 {
+		bits = 0
+	}
+	space := math.Ldexp(1, bits)
+	if float64(nodes) > space {
+		return 1
+	}
+	n := float64(nodes)
+	return -math.Expm1(-n * (n - 1) / (2 * space))
+}
+
+// 返回本机第一个私有 IPv4 地址的整数形式
+func privateIPv4() (uint32, error) {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return 0, err
+	}
+	return firstPrivateIPv4(addrs)
+}
+
+// 返回 addrs 中第一个位于 10.0.0.0/8、172.16.0.0/12 或 192.168.0.0/16 的 IPv4 地址的整数形式
+// 公网地址的低位在不同机房之间没有分配上的关联，不适合用于推导节点号
+func firstPrivateIPv4(addrs []net.Addr) (uint32, error) {
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		if ip := ipNet.IP.To4(); ip != nil && ip.IsPrivate() {
+			return binary.BigEndian.Uint32(ip), nil
+		}
+	}
+	return 0, ErrNoIPv4Address
+}
@@ -0,0 +1,123 @@
+package snowflake
+
+import (
+	"errors"
+	"math"
+	"net"
+	"testing"
+)
+
+func TestNodeIDRoundTrip(t *testing.T) {
+	for _, nodeID := range []int64{0, 1, 31, 32, 555, 1023} {
+		g, err := NewIDGeneratorFromNode(nodeID)
+		if err != nil {
+			t.Fatalf("node %d: %v", nodeID, err)
+		}
+		if g.IDCID != nodeID>>machineIDBits || g.machineID != nodeID&maxMachineID {
+			t.Fatalf("node %d: got idc %d machine %d", nodeID, g.IDCID, g.machineID)
+		}
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := NodeIDOf(id); got != nodeID {
+			t.Fatalf("NodeIDOf: got %d, want %d", got, nodeID)
+		}
+	}
+	for _, nodeID := range []int64{-1, 1024} {
+		if _, err := NewIDGeneratorFromNode(nodeID); !errors.Is(err, ErrInvaildNodeID) {
+			t.Fatalf("node %d: got %v, want ErrInvaildNodeID", nodeID, err)
+		}
+	}
+}
+
+func TestPodOrdinal(t *testing.T) {
+	for _, tc := range []struct {
+		hostname string
+		want     int64
+		err      error
+	}{
+		{"app-3", 3, nil},
+		{"web-db-12", 12, nil},
+		{"app-0", 0, nil},
+		{"app-3.app.default.svc.cluster.local", 3, nil},
+		{"kafka7", 7, nil},
+		{"42", 42, nil},
+		{"app", 0, ErrNoPodOrdinal},
+		{"app-", 0, ErrNoPodOrdinal},
+		{"app.3", 0, ErrNoPodOrdinal},
+		{"", 0, ErrNoPodOrdinal},
+		{"app-99999999999999999999", 0, ErrNoPodOrdinal},
+	} {
+		got, err := podOrdinal(tc.hostname)
+		if !errors.Is(err, tc.err) {
+			t.Fatalf("podOrdinal(%q): got error %v, want %v", tc.hostname, err, tc.err)
+		}
+		if err == nil && got != tc.want {
+			t.Fatalf("podOrdinal(%q) = %d, want %d", tc.hostname, got, tc.want)
+		}
+	}
+}
+
+func TestFirstPrivateIPv4(t *testing.T) {
+	addr := func(s string) net.Addr {
+		return &net.IPNet{IP: net.ParseIP(s), Mask: net.CIDRMask(24, 32)}
+	}
+	for _, tc := range []struct {
+		addrs []string
+		want  string
+	}{
+		{[]string{"127.0.0.1", "8.8.8.8", "10.1.2.3"}, "10.1.2.3"},
+		{[]string{"172.15.0.1", "172.32.0.1", "172.16.0.5"}, "172.16.0.5"},
+		{[]string{"fd00::1", "192.168.3.4", "10.1.2.3"}, "192.168.3.4"},
+		{[]string{"127.0.0.1", "8.8.8.8", "172.32.0.1", "fd00::1"}, ""},
+	} {
+		var addrs []net.Addr
+		for _, a := range tc.addrs {
+			addrs = append(addrs, addr(a))
+		}
+		got, err := firstPrivateIPv4(addrs)
+		if tc.want == "" {
+			if !errors.Is(err, ErrNoIPv4Address) {
+				t.Fatalf("%v: got %v, want ErrNoIPv4Address", tc.addrs, err)
+			}
+			continue
+		}
+		if err != nil || got != binaryIPv4(net.ParseIP(tc.want)) {
+			t.Fatalf("%v: got %d, %v, want %s", tc.addrs, got, err, tc.want)
+		}
+	}
+}
+
+func binaryIPv4(ip net.IP) uint32 {
+	ip = ip.To4()
+	return uint32(ip[0])<<24 | uint32(ip[1])<<16 | uint32(ip[2])<<8 | uint32(ip[3])
+}
+
+func TestCollisionProbability(t *testing.T) {
+	for _, tc := range []struct {
+		nodes, bits int
+		want        float64
+	}{
+		{0, 5, 0},
+		{1, 5, 0},
+		{2, 10, 1 - math.Exp(-1.0/1024)},
+		{7, 5, 1 - math.Exp(-42.0/64)}, // 约 0.48
+		{33, 5, 1},
+		{2, 0, 1},
+	} {
+		if got := CollisionProbability(tc.nodes, tc.bits); math.Abs(got-tc.want) > 1e-12 {
+			t.Fatalf("CollisionProbability(%d, %d) = %v, want %v", tc.nodes, tc.bits, got, tc.want)
+		}
+	}
+
+	// 与生日问题的精确值相比误差不大
+	exact := 1.0
+	for i := 0; i < 7; i++ {
+		exact *= float64(32-i) / 32
+	}
+	exact = 1 - exact // 约 0.51
+	if got := CollisionProbability(7, 5); got > exact || exact-got > 0.03 {
+		t.Fatalf("got %v, want slightly below the exact %v", got, exact)
+	}
+}
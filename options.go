@@ -0,0 +1,169 @@
+package snowflake
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Option 用于在构造 IDGenerator 时调整其配置
+type Option func(*IDGenerator)
+
+// WithClockBackTolerance 设置可容忍的时钟回拨范围
+// 回拨幅度不超过 tolerance 时，Generate 会等待时钟追上上一次生成 ID 的毫秒时间，而不是直接返回 ErrClockBack
+// 超过 tolerance 的回拨仍然返回 ErrClockBack，默认为 0，即不容忍任何回拨
+func WithClockBackTolerance(tolerance time.Duration) Option {
+	return func(g *IDGenerator) {
+		g.clockBackTolerance = tolerance.Milliseconds()
+	}
+}
+
+// WithClock 替换 IDGenerator 使用的时间源，主要用于测试
+func WithClock(clock Clock) Option {
+	return func(g *IDGenerator) {
+		g.clock = clock
+	}
+}
+
+// WithDriftCallback 检测系统墙上时钟相对单调时钟的漂移，漂移变化超过 1 毫秒时调用 callback，
+// delta 为自上次回调以来新增的漂移量，负数表示墙上时钟被向回调整，可用于将 ErrClockBack 与 NTP 调整关联起来
+// 仅用于诊断，漂移基于系统时钟检测，生成 ID 仍使用 WithClock 设置的时间源，与选项的顺序无关；
+// 回调在释放生成器的锁之后调用，可以在回调中使用本生成器；未设置时没有任何额外开销
+func WithDriftCallback(callback func(delta time.Duration)) Option {
+	return func(g *IDGenerator) {
+		g.driftCallback = callback
+	}
+}
+
+// WithTimestampBits 设置时间戳占用的 bit 位，默认 41 位，约可使用 69 年
+// 时间戳超出该位数后 Generate 返回 ErrTimestampOverflow
+func WithTimestampBits(n int) Option {
+	return func(g *IDGenerator) {
+		g.layout.timestampBits = n
+	}
+}
+
+// WithSequenceBits 设置序列号占用的 bit 位，默认 12 位
+// 序列号、机器号、IDC 号与时间戳的总位数不能超过 63，否则构造时返回 ErrInvaildLayout
+func WithSequenceBits(n int) Option {
+	return func(g *IDGenerator) {
+		g.layout.sequenceIDBits = n
+	}
+}
+
+// WithMachineBits 设置机器号占用的 bit 位，默认 5 位
+func WithMachineBits(n int) Option {
+	return func(g *IDGenerator) {
+		g.layout.machineIDBits = n
+	}
+}
+
+// WithIDCBits 设置 IDC 号占用的 bit 位，默认 5 位
+func WithIDCBits(n int) Option {
+	return func(g *IDGenerator) {
+		g.layout.idcIDBits = n
+	}
+}
+
+// WithSignedMode 声明该 IDGenerator 会通过 GenerateWithFlag 使用符号位作为业务标记位，IsValid 不再将负数 ID 视为非法
+func WithSignedMode() Option {
+	return func(g *IDGenerator) {
+		g.signed = true
+	}
+}
+
+// SequenceOverflowPolicy 同一毫秒内序列号耗尽时的处理策略
+type SequenceOverflowPolicy int
+
+const (
+	WaitNextMilli SequenceOverflowPolicy = iota // 等待到下一毫秒后继续生成，默认策略
+	ReturnError                                 // 立即返回 ErrSequenceExhausted，便于调用方主动降载
+)
+
+// WithSequenceOverflowPolicy 设置同一毫秒内序列号耗尽时的处理策略
+func WithSequenceOverflowPolicy(policy SequenceOverflowPolicy) Option {
+	return func(g *IDGenerator) {
+		g.overflowPolicy = policy
+	}
+}
+
+// WithRandomizedSequenceStart 每进入一个新毫秒，序列号从 [0, (maxSequenceID+1)/2) 内的随机值开始递增，
+// 避免同一毫秒内的 ID 总是从序列号 0 开始而易于猜测；同一毫秒内序列号仍严格递增，唯一性不受影响
+// 代价是每毫秒可用的序列号最坏减少一半，默认布局下最少为 2048 个；r 只在持锁时使用，无需并发安全
+func WithRandomizedSequenceStart(r *rand.Rand) Option {
+	return func(g *IDGenerator) {
+		g.sequenceRand = r
+	}
+}
+
+// WithReservedZero 将 IDC 号 0 和机器号 0 保留为"未分配"，构造时传入 0 分别返回 ErrInvaildIDCID 和 ErrInvaildMachineID，
+// 用于避免忘记配置而使用默认零值上线
+func WithReservedZero() Option {
+	return func(g *IDGenerator) {
+		g.reservedZero = true
+	}
+}
+
+// WithBackoff 设置等待时钟前进(序列号耗尽、容忍范围内的时钟回拨)时的退避策略，默认为 GoschedBackoff
+// 对延迟敏感可使用 SpinBackoff，对 CPU 占用敏感可使用 SleepBackoff；b 为 nil 时不做修改
+func WithBackoff(b Backoff) Option {
+	return func(g *IDGenerator) {
+		if b != nil {
+			g.backoff = b
+		}
+	}
+}
+
+// WithFieldOrder 设置 ID 中各字段从高位到低位的排列顺序，默认为时间戳、IDC 号、机器号、序列号
+// order 必须恰好包含 FieldTimestamp、FieldIDC、FieldMachine、FieldSequence 各一次，否则构造时返回 ErrInvaildFieldOrder
+// 例如将节点字段放在时间戳之前可以把不同节点的写入分散到索引的不同区间；
+// 注意：时间戳不在最高位时 ID 不再按生成时间有序，NewIDGeneratorContinuing 的递增保证也不再成立，
+// 这类 ID 只能通过本 IDGenerator 的 Decompose 反解，包级函数 Decompose 按默认顺序解析
+func WithFieldOrder(order []Field) Option {
+	return func(g *IDGenerator) {
+		g.layout.order = append([]Field(nil), order...)
+	}
+}
+
+// WithoutStreamRecovery 关闭 Stream 后台 goroutine 的 panic 恢复，panic 会照常传播并使进程崩溃，便于调试时获取完整现场
+func WithoutStreamRecovery() Option {
+	return func(g *IDGenerator) {
+		g.streamNoRecover = true
+	}
+}
+
+// WithStrictChecks 开启严格校验：Generate、Reserve 等每次拼接前校验时间戳、IDC 号、机器号、序列号是否都在各自的位数范围内，
+// 任一字段越界时返回描述具体字段的错误(可用 errors.Is 判断 ErrInvaildIDCID 等)，而不是静默生成损坏的 ID
+// 例如构造后直接修改了导出的 IDCID 字段、或 bit 布局配置有误时可以及早发现；
+// 开启后不再使用无锁快速路径，建议只在调试或加固场景下使用
+func WithStrictChecks() Option {
+	return func(g *IDGenerator) {
+		g.strict = true
+	}
+}
+
+// WithChecksumBit 将 ID 的最低位用作奇偶校验位，使低 63 位中 1 的个数恒为偶数，可用 VerifyChecksum 检测传输中的单比特翻转
+// 校验位从序列号中扣除，MaxSequenceID 减少一位，每毫秒可生成的 ID 数减半；其余字段整体左移一位，
+// 因此这类 ID 只能通过本 IDGenerator 的 Decompose 反解，包级函数 Decompose 按默认布局解析
+func WithChecksumBit() Option {
+	return func(g *IDGenerator) {
+		g.layout.checksum = true
+	}
+}
+
+// WithRegionBits 在 IDC 号之上增加 n 位区域号，用于 IDC 之上还有一级地域划分的部署，区域号通过 NewIDGeneratorWithRegion 传入
+// 总位数超过 63 时区域号占用的位数从时间戳中扣除，默认布局下每增加一位区域号，可使用的年限减半；
+// 也可配合 WithTimestampBits 或 WithSequenceBits 自行让出位数，此时不再扣除时间戳；
+// 开启后 ID 只能通过本 IDGenerator 的 Decompose 或 DecomposeRegion 反解，包级函数 Decompose 按默认布局解析
+func WithRegionBits(n int) Option {
+	return func(g *IDGenerator) {
+		g.layout.regionBits = n
+	}
+}
+
+// WithPaddedString 使 GenerateString 输出左侧补零的定宽十进制字符串，宽度为当前 bit 布局下最大 ID 的十进制位数，
+// 默认布局下为 19 位；定宽后字符串按字典序排序与 ID 的数值顺序一致，便于日志对齐和用作可排序的文件名
+func WithPaddedString() Option {
+	return func(g *IDGenerator) {
+		g.padded = true
+	}
+}
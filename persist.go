@@ -0,0 +1,99 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"io"
+	"time"
+)
+
+const persistInterval = 1000 // 每次持久化的高水位领先当前毫秒的时长，毫秒
+
+// WithPersistence 将 lastMilli 高水位持久化到 rw，用于进程重启后避免时钟落后导致生成重复 ID
+// 构造时读取 rw 中已保存的高水位，之后生成的 ID 毫秒时间一定晚于该高水位；
+// Generate 在进入新毫秒且追上已持久化的高水位时，以 8 字节大端序写入领先当前毫秒 1 秒的租约，
+// 因此即使进程崩溃，已生成的 ID 的毫秒时间也不会晚于持久化的高水位；Close 时写入实际的 lastMilli
+// 高水位晚于当前时钟不超过 1 秒时(通常是崩溃前写入的租约)构造会等待时钟追上，超过 1 秒时返回 ErrClockBack，
+// 配合 WithPersistenceWait 可改为一直等待
+// rw 实现了 io.WriterAt 与 Truncate(如 *os.File，打开时不能带 O_APPEND)时原地覆盖，文件大小固定为 8 字节；
+// 否则每次追加一条记录，约每秒 8 字节，构造时 rw 的全部内容会被读入内存，需由调用方定期轮转
+func WithPersistence(rw io.ReadWriter) Option {
+	return func(g *IDGenerator) {
+		g.persist = rw
+	}
+}
+
+// WithPersistenceWait 构造时若持久化的高水位晚于当前时钟，等待时钟追上而不是返回 ErrClockBack
+func WithPersistenceWait() Option {
+	return func(g *IDGenerator) {
+		g.persistWait = true
+	}
+}
+
+// 可原地覆盖的持久化目标，如 *os.File
+type persistFile interface {
+	io.WriterAt
+	Truncate(size int64) error
+}
+
+// 读取持久化的高水位并据此初始化 state，没有可用记录时保持初始状态
+func (g *IDGenerator) restorePersisted() error {
+	data, err := io.ReadAll(g.persist)
+	if err != nil {
+		return err
+	}
+	// 只取最后一条完整记录，忽略崩溃时可能写了一半的尾部
+	n := len(data) / 8 * 8
+	if n == 0 {
+		return nil
+	}
+	milli := int64(binary.BigEndian.Uint64(data[n-8 : n]))
+	if f, ok := g.persist.(persistFile); ok && len(data) > 8 {
+		// 此前以追加方式写入的历史记录只保留最后一条，之后原地覆盖
+		if _, err := f.WriteAt(data[n-8:n], 0); err != nil {
+			return err
+		}
+		if err := f.Truncate(8); err != nil {
+			return err
+		}
+	}
+	if milli < g.epoch {
+		return nil
+	}
+	if now := g.now(); now < milli {
+		if !g.persistWait && milli-now > persistInterval {
+			return &ClockBackError{LastMilli: milli, NowMilli: now}
+		}
+		for now < milli {
+			time.Sleep(time.Duration(milli-now) * time.Millisecond)
+			now = g.now()
+		}
+	}
+	// 序列号置为最大值，使下一个 ID 必须进入高水位之后的毫秒
+	g.state.Store(g.pack(milli, g.layout.maxSequenceID))
+	g.persistedMilli = milli
+	return nil
+}
+
+// 进入新毫秒 now 时，now 追上已持久化的高水位则写入新的租约，调用方需持有锁
+func (g *IDGenerator) flushPersisted(now int64) error {
+	if g.persist == nil || now < g.persistedMilli {
+		return nil
+	}
+	return g.writePersisted(now + persistInterval)
+}
+
+func (g *IDGenerator) writePersisted(milli int64) error {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], uint64(milli))
+	var err error
+	if f, ok := g.persist.(persistFile); ok {
+		_, err = f.WriteAt(buf[:], 0)
+	} else {
+		_, err = g.persist.Write(buf[:])
+	}
+	if err != nil {
+		return err
+	}
+	g.persistedMilli = milli
+	return nil
+}
@@ -0,0 +1,119 @@
+package snowflake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func persistedRecord(milli int64) []byte {
+	var b [8]byte
+	binary.BigEndian.PutUint64(b[:], uint64(milli))
+	return b[:]
+}
+
+func TestPersistenceLease(t *testing.T) {
+	start := int64(epoch + 100000)
+	clock := newManualClock(start)
+	var buf bytes.Buffer
+	g := newTestGenerator(t, clock, WithPersistence(&buf))
+	last, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 进入新毫秒时写入领先 1 秒的租约，租约内不再写入
+	if got := int64(binary.BigEndian.Uint64(buf.Bytes())); buf.Len() != 8 || got != start+persistInterval {
+		t.Fatalf("got %d bytes with lease %d, want 8 bytes with %d", buf.Len(), got, start+persistInterval)
+	}
+	for i := 0; i < 10; i++ {
+		clock.Add(1)
+		if last, err = g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if buf.Len() != 8 {
+		t.Fatalf("got %d bytes within the lease, want 8", buf.Len())
+	}
+
+	// 模拟崩溃后重启：读到的高水位是崩溃前写入的租约，重启后的 ID 晚于崩溃前生成的所有 ID
+	clock.Set(start + persistInterval + 1)
+	g = newTestGenerator(t, clock, WithPersistence(bytes.NewBuffer(buf.Bytes())))
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id <= last {
+		t.Fatalf("got %d after restart, want greater than %d", id, last)
+	}
+
+	// 高水位领先时钟超过 1 秒
+	clock.Set(start)
+	behind := bytes.NewBuffer(persistedRecord(start + persistInterval + 1))
+	if _, err := NewIDGenerator(1, 1, WithClock(clock), WithPersistence(behind)); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want ErrClockBack", err)
+	}
+}
+
+func TestPersistenceWait(t *testing.T) {
+	// 租约领先真实时钟不超过 1 秒时等待时钟追上
+	lease := time.Now().UnixMilli() + 30
+	begin := time.Now()
+	g, err := NewIDGenerator(1, 1, WithPersistence(bytes.NewBuffer(persistedRecord(lease))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if time.Since(begin) < 20*time.Millisecond {
+		t.Fatalf("returned after %v, want to wait for the lease", time.Since(begin))
+	}
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _, _ := g.Decompose(id); ts <= lease {
+		t.Fatalf("got timestamp %d, want after the lease %d", ts, lease)
+	}
+}
+
+func TestPersistenceFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "high-water")
+	start := int64(epoch + 100000)
+	// 以追加方式写入的历史记录，构造时压缩为最后一条
+	if err := os.WriteFile(path, append(append(persistedRecord(start-3000), persistedRecord(start-2000)...), persistedRecord(start-1000)...), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	clock := newManualClock(start)
+	g := newTestGenerator(t, clock, WithPersistence(f))
+	for i := 0; i < 3*persistInterval; i += 100 {
+		clock.Add(100)
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != 8 {
+		t.Fatalf("got %d bytes, want the file kept at 8", len(data))
+	}
+
+	// 关闭时用实际的 lastMilli 替换领先的租约
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if data, err = os.ReadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := int64(binary.BigEndian.Uint64(data)); len(data) != 8 || got != clock.NowMilli() {
+		t.Fatalf("got %d bytes with %d after Close, want 8 bytes with %d", len(data), got, clock.NowMilli())
+	}
+}
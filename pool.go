@@ -0,0 +1,86 @@
+package snowflake
+
+import (
+	"errors"
+	"sync/atomic"
+)
+
+var (
+	ErrInvaildPool     = errors.New("IDGenerator: input invaild pool shards, need at least one shard and distinct machine IDs")
+	ErrPoolSharedState = errors.New("IDGenerator: pool shards can not share persistence, monotonic floor or sequence random source options")
+)
+
+// Pool 由多个 IDGenerator 分片组成，用于分散单个生成器的竞争
+// 每个分片占用同一 IDC 下互不相同的机器号，因此各分片生成的 ID 全局唯一；
+// 请求按轮询分配到各分片，同一分片内的 ID 严格递增，但 Pool 整体生成的 ID 不保证递增
+type Pool struct {
+	shards []*IDGenerator
+	next   atomic.Uint64 // 轮询计数
+}
+
+// NewPool 为 shardMachineIDs 中的每个机器号创建一个分片，opts 作用于所有分片
+// WithPersistence、WithMonotonicFloor 与 WithRandomizedSequenceStart 携带的持久化目标或随机源会被所有分片共用，
+// 分片之间互相覆盖高水位、并发使用非并发安全的随机源，因此 opts 中包含这些选项时返回 ErrPoolSharedState
+func NewPool(idcID int64, shardMachineIDs []int64, opts ...Option) (*Pool, error) {
+	if len(shardMachineIDs) == 0 {
+		return nil, ErrInvaildPool
+	}
+	if probe := applyOptions(epoch, opts); probe.persist != nil || probe.floorPath != "" || probe.sequenceRand != nil {
+		return nil, ErrPoolSharedState
+	}
+	seen := make(map[int64]struct{}, len(shardMachineIDs))
+	shards := make([]*IDGenerator, 0, len(shardMachineIDs))
+	for _, machineID := range shardMachineIDs {
+		if _, ok := seen[machineID]; ok {
+			return nil, ErrInvaildPool
+		}
+		seen[machineID] = struct{}{}
+		g, err := NewIDGenerator(idcID, machineID, opts...)
+		if err != nil {
+			return nil, err
+		}
+		shards = append(shards, g)
+	}
+	return &Pool{shards: shards}, nil
+}
+
+// Generate 轮询选取一个分片生成 ID
+func (p *Pool) Generate() (int64, error) {
+	i := (p.next.Add(1) - 1) % uint64(len(p.shards))
+	return p.shards[i].Generate()
+}
+
+// Stats 返回所有分片运行计数之和
+// 各分片的计数分别原子读取，并发生成时结果不是所有分片同一时刻的快照，但每个分片的计数不会被重复或遗漏
+func (p *Pool) Stats() Stats {
+	var total Stats
+	for _, g := range p.shards {
+		s := g.Stats()
+		total.Generated += s.Generated
+		total.SequenceWaits += s.SequenceWaits
+		total.ClockBackEvents += s.ClockBackEvents
+	}
+	return total
+}
+
+// RotatingGenerator 在固定 IDC 下轮流使用一组机器号生成 ID，适用于一个进程合法占用多个机器号的场景，
+// 例如迁移工具代替已下线的节点继续生成 ID
+// 唯一性：每个机器号由独立的 IDGenerator 负责，同一机器号内的 ID 按毫秒与序列号保证不重复，
+// 不同机器号生成的 ID 机器号字段不同，因此整体不会重复；前提是这些机器号不再被其他进程同时使用
+type RotatingGenerator struct {
+	pool *Pool
+}
+
+// NewRotatingGenerator 为 machineIDs 中的每个机器号创建一个生成器，machineIDs 为空或有重复时返回 ErrInvaildPool
+func NewRotatingGenerator(idcID int64, machineIDs []int64, opts ...Option) (*RotatingGenerator, error) {
+	pool, err := NewPool(idcID, machineIDs, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &RotatingGenerator{pool: pool}, nil
+}
+
+// Generate 每次调用按 machineIDs 的顺序切换到下一个机器号生成 ID，用完一轮后从头开始
+func (r *RotatingGenerator) Generate() (int64, error) {
+	return r.pool.Generate()
+}
@@ -0,0 +1,126 @@
+package snowflake
+
+import (
+	"bytes"
+	"errors"
+	"math/rand"
+	"path/filepath"
+	"runtime"
+	"sync"
+	"testing"
+)
+
+func poolMachineIDs(n int) []int64 {
+	if limit := int(MaxMachineID()) + 1; n > limit {
+		n = limit
+	}
+	ids := make([]int64, n)
+	for i := range ids {
+		ids[i] = int64(i)
+	}
+	return ids
+}
+
+// 单个生成器每毫秒最多生成 4096 个 ID，Pool 按 GOMAXPROCS 分片后容量随分片数增长
+func BenchmarkPool(b *testing.B) {
+	b.Run("single", func(b *testing.B) {
+		g := newBenchGenerator(b)
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := g.Generate(); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+	b.Run("pool", func(b *testing.B) {
+		p, err := NewPool(1, poolMachineIDs(runtime.GOMAXPROCS(0)))
+		if err != nil {
+			b.Fatal(err)
+		}
+		b.RunParallel(func(pb *testing.PB) {
+			for pb.Next() {
+				if _, err := p.Generate(); err != nil {
+					b.Error(err)
+					return
+				}
+			}
+		})
+	})
+}
+
+func TestPoolStats(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	p, err := NewPool(1, []int64{3, 5, 7, 9}, WithClock(clock), WithBackoff(&tickBackoff{clock: clock}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	const goroutines, perGoroutine = 8, 1000
+	var wg sync.WaitGroup
+	for i := 0; i < goroutines; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				if _, err := p.Generate(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	var sum int64
+	for _, g := range p.shards {
+		sum += g.Stats().Generated
+	}
+	if total := p.Stats().Generated; total != goroutines*perGoroutine || sum != total {
+		t.Fatalf("got pool total %d, shard sum %d, want %d", total, sum, goroutines*perGoroutine)
+	}
+}
+
+func TestPoolSharedState(t *testing.T) {
+	for name, opt := range map[string]Option{
+		"persistence":               WithPersistence(&bytes.Buffer{}),
+		"monotonic floor":           WithMonotonicFloor(filepath.Join(t.TempDir(), "floor")),
+		"randomized sequence start": WithRandomizedSequenceStart(rand.New(rand.NewSource(1))),
+	} {
+		if _, err := NewPool(1, []int64{1, 2}, opt); !errors.Is(err, ErrPoolSharedState) {
+			t.Fatalf("%s: got %v, want ErrPoolSharedState", name, err)
+		}
+	}
+}
+
+func TestRotatingGenerator(t *testing.T) {
+	machineIDs := []int64{4, 9, 2}
+	clock := newManualClock(int64(epoch + 1000))
+	r, err := NewRotatingGenerator(6, machineIDs, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int64]bool{}
+	for i := 0; i < 30; i++ {
+		if i%10 == 0 {
+			clock.Add(1)
+		}
+		id, err := r.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[id] {
+			t.Fatalf("duplicate ID %d", id)
+		}
+		seen[id] = true
+		if _, idcID, machineID, _ := Decompose(id); idcID != 6 || machineID != machineIDs[i%len(machineIDs)] {
+			t.Fatalf("ID %d: got idc %d machine %d, want 6 %d", i, idcID, machineID, machineIDs[i%len(machineIDs)])
+		}
+	}
+
+	for _, ids := range [][]int64{nil, {1, 2, 1}} {
+		if _, err := NewRotatingGenerator(6, ids); !errors.Is(err, ErrInvaildPool) {
+			t.Fatalf("machine IDs %v: got %v, want ErrInvaildPool", ids, err)
+		}
+	}
+}
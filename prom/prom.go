@@ -0,0 +1,44 @@
+// Package prom 将 snowflake.IDGenerator 的运行计数导出为 Prometheus 指标
+// 独立为子模块(prom/go.mod)，引入核心包时不会引入 Prometheus 的依赖
+package prom
+
+import (
+	snowflake "github.com/leantli/classic_snowflake"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	generatedDesc = prometheus.NewDesc(
+		"snowflake_generated_total", "Total number of IDs generated.", nil, nil)
+	sequenceWaitsDesc = prometheus.NewDesc(
+		"snowflake_sequence_waits_total", "Number of times sequence exhaustion forced a wait for the next millisecond.", nil, nil)
+	clockBackEventsDesc = prometheus.NewDesc(
+		"snowflake_clock_back_events_total", "Number of clock rollback events detected.", nil, nil)
+)
+
+var _ prometheus.Collector = (*Collector)(nil)
+
+// Collector 以 Prometheus 计数器导出 IDGenerator.Stats
+type Collector struct {
+	g *snowflake.IDGenerator
+}
+
+// NewCollector 返回 g 的 Collector，可直接通过 prometheus.MustRegister 注册
+func NewCollector(g *snowflake.IDGenerator) *Collector {
+	return &Collector{g: g}
+}
+
+// Describe 实现 prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- generatedDesc
+	ch <- sequenceWaitsDesc
+	ch <- clockBackEventsDesc
+}
+
+// Collect 实现 prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.g.Stats()
+	ch <- prometheus.MustNewConstMetric(generatedDesc, prometheus.CounterValue, float64(stats.Generated))
+	ch <- prometheus.MustNewConstMetric(sequenceWaitsDesc, prometheus.CounterValue, float64(stats.SequenceWaits))
+	ch <- prometheus.MustNewConstMetric(clockBackEventsDesc, prometheus.CounterValue, float64(stats.ClockBackEvents))
+}
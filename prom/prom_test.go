@@ -0,0 +1,47 @@
+package prom
+
+import (
+	"strings"
+	"testing"
+
+	snowflake "github.com/leantli/classic_snowflake"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestCollector(t *testing.T) {
+	g, err := snowflake.NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reg := prometheus.NewPedanticRegistry()
+	if err := reg.Register(NewCollector(g)); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.GenerateN(10); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// 生成 15 个 ID 不会用完一毫秒内的序列号，也不会发生时钟回拨
+	expected := `
+# HELP snowflake_generated_total Total number of IDs generated.
+# TYPE snowflake_generated_total counter
+snowflake_generated_total 15
+# HELP snowflake_sequence_waits_total Number of times sequence exhaustion forced a wait for the next millisecond.
+# TYPE snowflake_sequence_waits_total counter
+snowflake_sequence_waits_total 0
+# HELP snowflake_clock_back_events_total Number of clock rollback events detected.
+# TYPE snowflake_clock_back_events_total counter
+snowflake_clock_back_events_total 0
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(expected)); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := testutil.GatherAndCount(reg); err != nil || n != 3 {
+		t.Fatalf("got %d metrics, %v, want 3", n, err)
+	}
+}
@@ -0,0 +1,6 @@
+//go:build race
+
+package snowflake
+
+// race 检测会改变逃逸分析的结果，依赖分配次数的测试在开启时跳过
+const raceEnabled = true
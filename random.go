@@ -0,0 +1,26 @@
+package snowflake
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+)
+
+// WithRandomBits 将序列号字段的低 n 位替换为来自 crypto/rand 的随机值，其余高位仍作为每毫秒递增的计数
+// 随机部分使相邻 ID 无法被猜测，计数部分保证同一毫秒内不重复；每毫秒可生成的 ID 数随之降为原来的 1/2^n，
+// 即 MaxSequenceID 变为 (原 MaxSequenceID+1)>>n - 1，n 不能超过序列号位数，否则构造时返回 ErrInvaildLayout
+// Decompose 返回的序列号只包含计数部分；开启后每次生成都要读取 crypto/rand，开销明显高于默认配置
+func WithRandomBits(n int) Option {
+	return func(g *IDGenerator) {
+		g.layout.randomBits = n
+	}
+}
+
+// 返回 [0, mask] 内的密码学安全随机数，mask 为低位全 1 的掩码
+func cryptoRandom(mask int64) int64 {
+	var b [8]byte
+	// crypto/rand 读取失败意味着系统随机源不可用，此时无法提供不可猜测的 ID，与其静默退化不如直接暴露问题
+	if _, err := rand.Read(b[:]); err != nil {
+		panic(err)
+	}
+	return int64(binary.BigEndian.Uint64(b[:])) & mask
+}
@@ -0,0 +1,84 @@
+package snowflake
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestRandomBitsUnique(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithRandomBits(4), WithBackoff(&tickBackoff{clock: clock}))
+	if g.MaxSequenceID() != 255 {
+		t.Fatalf("got MaxSequenceID %d, want 255", g.MaxSequenceID())
+	}
+	const goroutines, perGoroutine = 8, 2000
+	results := make([][]int64, goroutines)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < perGoroutine; j++ {
+				id, err := g.Generate()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				results[i] = append(results[i], id)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	seen := make(map[int64]bool, goroutines*perGoroutine)
+	randoms := map[int64]bool{}
+	for _, ids := range results {
+		for _, id := range ids {
+			if seen[id] {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = true
+			randoms[id&0x0f] = true
+		}
+	}
+	// 16 000 个 ID 的随机部分覆盖全部 16 个取值
+	if len(randoms) != 16 {
+		t.Fatalf("got %d distinct random parts, want 16", len(randoms))
+	}
+}
+
+func TestRandomBitsLast(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{{"fast", nil}, {"strict", []Option{WithStrictChecks()}}} {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := newManualClock(int64(epoch + 1000))
+			g := newTestGenerator(t, clock, append(tc.opts, WithRandomBits(8), WithBackoff(&tickBackoff{clock: clock}))...)
+			if _, ok := g.Last(); ok {
+				t.Fatal("Last reported an ID before any was generated")
+			}
+			var randomSet bool
+			for i := 0; i < 50; i++ {
+				id, err := g.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if last, ok := g.Last(); !ok || last != id {
+					t.Fatalf("Last got (%d, %v), want %d", last, ok, id)
+				}
+				randomSet = randomSet || id&0xff != 0
+			}
+			if !randomSet {
+				t.Fatal("random bits were always zero")
+			}
+			_, ids, err := g.Reserve(10)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if last, _ := g.Last(); last != ids[len(ids)-1] {
+				t.Fatalf("Last after Reserve got %d, want %d", last, ids[len(ids)-1])
+			}
+		})
+	}
+}
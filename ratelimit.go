@@ -0,0 +1,61 @@
+package snowflake
+
+import (
+	"errors"
+	"sync"
+)
+
+var ErrRateLimited = errors.New("IDGenerator: rate limited, generation budget exhausted")
+
+// WithRateLimit 限制生成速率不超过每秒 perSecond 个 ID，超出时 Generate 立即返回 ErrRateLimited 而不是阻塞
+// 采用令牌桶，桶容量为 perSecond，即允许最多一秒额度的突发；令牌按本 IDGenerator 的时间源补充
+// 限流作用于 Generate、GenerateContext、GenerateSpaced 及基于它们的生成方法，GenerateN、Reserve 一次取出与 ID 数相同的令牌，
+// 令牌不足时整批返回 ErrRateLimited，因此单批超过 perSecond 个 ID 总会被拒绝；GenerateAt 等回填接口不受限制
+// 开启后不再使用无锁快速路径；perSecond <= 0 时不限流
+func WithRateLimit(perSecond int) Option {
+	return func(g *IDGenerator) {
+		if perSecond <= 0 {
+			g.limiter = nil
+			return
+		}
+		g.limiter = &rateLimiter{rate: int64(perSecond), lastMilli: -1}
+	}
+}
+
+// rateLimiter 令牌桶，使用独立的锁，不与生成 ID 的锁嵌套
+// 令牌以千分之一个为单位计数，每毫秒补充 rate 个单位，生成一个 ID 消耗 1000 个单位
+type rateLimiter struct {
+	mutex     sync.Mutex
+	rate      int64 // 每秒允许生成的 ID 数，同时是桶容量
+	tokens    int64 // 当前令牌数，单位为千分之一个
+	lastMilli int64 // 上一次补充令牌的毫秒时间，-1 表示尚未使用
+}
+
+// 尝试取出一个令牌
+func (l *rateLimiter) allow(now int64) bool {
+	return l.allowN(now, 1)
+}
+
+// 尝试一次取出 n 个令牌，不足时不取出任何令牌
+func (l *rateLimiter) allowN(now, n int64) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	capacity := l.rate * 1000
+	if l.lastMilli == -1 {
+		l.tokens = capacity
+	} else if elapsed := now - l.lastMilli; elapsed > 0 {
+		if elapsed >= 1000 {
+			l.tokens = capacity
+		} else if l.tokens += elapsed * l.rate; l.tokens > capacity {
+			l.tokens = capacity
+		}
+	}
+	if now > l.lastMilli {
+		l.lastMilli = now
+	}
+	if l.tokens < n*1000 {
+		return false
+	}
+	l.tokens -= n * 1000
+	return true
+}
@@ -0,0 +1,72 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRateLimit(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithRateLimit(100))
+	// 桶初始为满，允许一秒额度的突发
+	for i := 0; i < 100; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatalf("ID %d within the burst: %v", i, err)
+		}
+	}
+	if _, err := g.Generate(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("got %v, want ErrRateLimited", err)
+	}
+
+	// 之后按 100 个每秒补充，即每 10ms 一个
+	clock.Add(9)
+	if _, err := g.Generate(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("after 9ms: got %v, want ErrRateLimited", err)
+	}
+	clock.Add(1)
+	if _, err := g.Generate(); err != nil {
+		t.Fatalf("after 10ms: %v", err)
+	}
+
+	// 持续请求 10 秒，成功数约等于配置的速率
+	var generated int
+	for i := 0; i < 10000; i++ {
+		clock.Add(1)
+		for {
+			if _, err := g.Generate(); errors.Is(err, ErrRateLimited) {
+				break
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			generated++
+		}
+	}
+	if generated < 999 || generated > 1001 {
+		t.Fatalf("generated %d IDs in 10s, want about 1000", generated)
+	}
+}
+
+func TestRateLimitBatch(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	// GenerateSpaced 需要等待进入新的毫秒，每次等待补充 0.1 个令牌
+	g := newTestGenerator(t, clock, WithRateLimit(100), WithBackoff(&tickBackoff{clock: clock}))
+	if _, ids, err := g.Reserve(60); err != nil || len(ids) != 60 {
+		t.Fatalf("Reserve within the burst got %d IDs, %v", len(ids), err)
+	}
+	// 剩余 40 个令牌，不足时整批拒绝且不消耗令牌
+	if _, ids, err := g.Reserve(41); !errors.Is(err, ErrRateLimited) || len(ids) != 0 {
+		t.Fatalf("Reserve over the budget got %d IDs, %v, want ErrRateLimited", len(ids), err)
+	}
+	if ids, err := g.GenerateN(39); err != nil || len(ids) != 39 {
+		t.Fatalf("GenerateN within the budget got %d IDs, %v", len(ids), err)
+	}
+	if _, err := g.GenerateSpaced(); err != nil {
+		t.Fatalf("GenerateSpaced with the last token: %v", err)
+	}
+	if _, err := g.GenerateSpaced(); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("GenerateSpaced got %v, want ErrRateLimited", err)
+	}
+	if _, err := g.GenerateN(1); !errors.Is(err, ErrRateLimited) {
+		t.Fatalf("GenerateN got %v, want ErrRateLimited", err)
+	}
+}
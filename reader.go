@@ -0,0 +1,37 @@
+package snowflake
+
+import (
+	"encoding/binary"
+	"io"
+)
+
+var _ io.Reader = (*IDGenerator)(nil)
+
+// GenerateBytes 生成一个 ID 并返回其 8 字节大端序编码
+// 不带符号位的 ID 编码后按字节比较的顺序与 int64 比较的顺序一致，适合作为 KV 存储的有序 key
+func (g *IDGenerator) GenerateBytes() ([8]byte, error) {
+	var b [8]byte
+	id, err := g.Generate()
+	if err != nil {
+		return b, err
+	}
+	binary.BigEndian.PutUint64(b[:], uint64(id))
+	return b, nil
+}
+
+// Read 实现 io.Reader，向 p 中写入尽可能多的完整 ID，每个 ID 占 8 字节、大端序，可配合 io.Copy 写入文件或网络连接
+// p 不足 8 字节时返回 io.ErrShortBuffer，p 末尾不足 8 字节的部分保持不变；
+// 生成出错(如 ErrClockBack)时返回已写入的字节数及对应的错误
+func (g *IDGenerator) Read(p []byte) (n int, err error) {
+	if len(p) < 8 {
+		return 0, io.ErrShortBuffer
+	}
+	for ; n+8 <= len(p); n += 8 {
+		id, err := g.Generate()
+		if err != nil {
+			return n, err
+		}
+		binary.BigEndian.PutUint64(p[n:], uint64(id))
+	}
+	return n, nil
+}
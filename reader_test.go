@@ -0,0 +1,77 @@
+package snowflake
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"testing"
+)
+
+func TestRead(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	var last int64 = -1
+	for _, size := range []int{0, 7, 8, 9, 15, 16, 17, 800} {
+		p := bytes.Repeat([]byte{0xff}, size)
+		n, err := g.Read(p)
+		if size < 8 {
+			if n != 0 || !errors.Is(err, io.ErrShortBuffer) {
+				t.Fatalf("size %d: got (%d, %v), want (0, io.ErrShortBuffer)", size, n, err)
+			}
+			continue
+		}
+		if err != nil || n != size/8*8 {
+			t.Fatalf("size %d: got (%d, %v), want (%d, nil)", size, n, err, size/8*8)
+		}
+		for i := 0; i < n; i += 8 {
+			id := int64(binary.BigEndian.Uint64(p[i:]))
+			if id <= last {
+				t.Fatalf("size %d: got %d after %d, want increasing", size, id, last)
+			}
+			last = id
+		}
+		// 末尾不足 8 字节的部分保持不变
+		if !bytes.Equal(p[n:], bytes.Repeat([]byte{0xff}, size-n)) {
+			t.Fatalf("size %d: trailing bytes modified: %x", size, p[n:])
+		}
+	}
+
+	clock.Add(-10)
+	if n, err := g.Read(make([]byte, 16)); n != 0 || !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got (%d, %v), want (0, ErrClockBack)", n, err)
+	}
+}
+
+func TestGenerateBytesOrder(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	var prev [8]byte
+	var prevID int64 = -1
+	for i := 0; i < 10000; i++ {
+		if i%1000 == 0 {
+			clock.Add(1)
+		}
+		b, err := g.GenerateBytes()
+		if err != nil {
+			t.Fatal(err)
+		}
+		id := int64(binary.BigEndian.Uint64(b[:]))
+		if id <= prevID || bytes.Compare(b[:], prev[:]) <= 0 {
+			t.Fatalf("ID %d: bytes %x not ordered after %x", id, b, prev)
+		}
+		prev, prevID = b, id
+	}
+
+	// 任意两个非负 ID 的字节序与数值顺序一致
+	ids := []int64{0, 1, 255, 256, Compose(epoch+1000, 31, 31, 4095), 1 << 40, math.MaxInt64}
+	for i := 1; i < len(ids); i++ {
+		var a, b [8]byte
+		binary.BigEndian.PutUint64(a[:], uint64(ids[i-1]))
+		binary.BigEndian.PutUint64(b[:], uint64(ids[i]))
+		if bytes.Compare(a[:], b[:]) >= 0 {
+			t.Fatalf("%d encodes after %d", ids[i-1], ids[i])
+		}
+	}
+}
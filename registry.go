@@ -0,0 +1,55 @@
+package snowflake
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+)
+
+var ErrDuplicateNode = errors.New("IDGenerator: region ID, IDC ID and machine ID already in use by another generator")
+
+// 进程内正在使用的 (区域号, IDC 号, 机器号) 登记表，只登记通过 NewIDGeneratorChecked 等构造的生成器
+// 区域号不同的生成器 ID 中的区域号字段不同，可以使用相同的 IDC 号和机器号
+var registry = struct {
+	sync.Mutex
+	nodes map[[3]int64]struct{}
+}{nodes: make(map[[3]int64]struct{})}
+
+// NewIDGeneratorChecked 同 NewIDGenerator，但会在进程内登记 (idcID, machineID)，
+// 该组合已被另一个仍在使用的生成器登记时返回 ErrDuplicateNode，用于避免同一进程内误用相同的 IDC 号和机器号
+// 登记在生成器被垃圾回收时自动释放
+func NewIDGeneratorChecked(idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	return NewIDGeneratorWithRegionChecked(0, idcID, machineID, opts...)
+}
+
+// NewIDGeneratorWithRegionChecked 同 NewIDGeneratorWithRegion，登记方式同 NewIDGeneratorChecked，登记时包含区域号
+func NewIDGeneratorWithRegionChecked(regionID, idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	g, err := NewIDGeneratorWithRegion(regionID, idcID, machineID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	registry.Lock()
+	defer registry.Unlock()
+	if _, ok := registry.nodes[g.nodeKey()]; ok {
+		return nil, ErrDuplicateNode
+	}
+	registry.nodes[g.nodeKey()] = struct{}{}
+	g.registered = true
+	runtime.SetFinalizer(g, (*IDGenerator).unregister)
+	return g, nil
+}
+
+// 登记表中的键
+func (g *IDGenerator) nodeKey() [3]int64 {
+	return [3]int64{g.regionID, g.IDCID, g.machineID}
+}
+
+// 释放登记，可重复调用
+func (g *IDGenerator) unregister() {
+	registry.Lock()
+	defer registry.Unlock()
+	if g.registered {
+		delete(registry.nodes, g.nodeKey())
+		g.registered = false
+	}
+}
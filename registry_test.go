@@ -0,0 +1,48 @@
+package snowflake
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewIDGeneratorChecked(t *testing.T) {
+	g, err := NewIDGeneratorChecked(7, 21)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewIDGeneratorChecked(7, 21); !errors.Is(err, ErrDuplicateNode) {
+		t.Fatalf("second registration: got %v, want ErrDuplicateNode", err)
+	}
+	// 不同的组合互不影响
+	other, err := NewIDGeneratorChecked(7, 22)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	// Close 释放登记后可以重新登记
+	if err := g.Close(); err != nil {
+		t.Fatal(err)
+	}
+	again, err := NewIDGeneratorChecked(7, 21)
+	if err != nil {
+		t.Fatalf("after Close: %v", err)
+	}
+	again.Close()
+}
+
+func TestNewIDGeneratorWithRegionChecked(t *testing.T) {
+	g, err := NewIDGeneratorWithRegionChecked(1, 7, 23, WithRegionBits(2))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer g.Close()
+	// 区域号不同时可以使用相同的 IDC 号和机器号
+	other, err := NewIDGeneratorChecked(7, 23)
+	if err != nil {
+		t.Fatalf("another region: %v", err)
+	}
+	defer other.Close()
+	if _, err := NewIDGeneratorWithRegionChecked(1, 7, 23, WithRegionBits(2)); !errors.Is(err, ErrDuplicateNode) {
+		t.Fatalf("same region: got %v, want ErrDuplicateNode", err)
+	}
+}
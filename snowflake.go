@@ -1,8 +1,17 @@
-package main
+// Package snowflake 实现基于经典雪花算法的分布式 ID 生成器
+package snowflake
 
 import (
+	"context"
 	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"runtime"
+	"strconv"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -23,74 +32,1099 @@ const (
 	maxSequenceID  = ^(-1 << sequenceIDBits)        // 序列号的最大值 可以获得 sequenceIDBits 下的最数值，比如 bit=5 时，最大为 31
 	maxMachineID   = ^(-1 << machineIDBits)         // 机器号的最大值
 	maxIDCID       = ^(-1 << idcIDBits)             // IDC 号的最大值
-	epoch          = 1669046400000                  // 2022-11-22 00:00:00 的毫秒时间戳，开始使用时间
+	maxID          = ^(-1 << 63)                    // 去除符号位后 ID 的最大值，用于屏蔽符号位
+	flagBit        = -1 << 63                       // 最高位(符号位)，GenerateWithFlag 用作业务标记位
+	epoch          = 1669046400000                  // 2022-11-22 00:00:00 的毫秒时间戳，默认的开始使用时间
 )
 
 var (
-	ErrInvaildIDCID     = errors.New("IDGenerator: input invaild IDC ID")
-	ErrInvaildMachineID = errors.New("IDGenerator: input invaild machine ID")
-	ErrClockBack        = errors.New("IDGenerator: clock turn back, stop generating to avoid generating repeated ID")
+	ErrInvaildIDCID      = errors.New("IDGenerator: input invaild IDC ID")
+	ErrInvaildMachineID  = errors.New("IDGenerator: input invaild machine ID")
+	ErrInvaildRegionID   = errors.New("IDGenerator: input invaild region ID")
+	ErrClockBack         = errors.New("IDGenerator: clock turn back, stop generating to avoid generating repeated ID")
+	ErrClockBeforeEpoch  = errors.New("IDGenerator: clock is earlier than epoch, stop generating to avoid generating corrupt ID")
+	ErrInvaildSequenceID = errors.New("IDGenerator: input invaild sequence ID")
+	ErrClosed            = errors.New("IDGenerator: generator closed")
+	ErrInvaildTime       = errors.New("IDGenerator: input invaild time, time can not be earlier than epoch")
+	ErrInvaildEpoch      = errors.New("IDGenerator: input invaild epoch, epoch can not be later than now")
+	ErrSequenceExhausted = errors.New("IDGenerator: sequence exhausted in current millisecond")
+	ErrTimestampOverflow = errors.New("IDGenerator: timestamp overflow, time since epoch exceeds the timestamp bits")
+	ErrAtTimeBack        = errors.New("IDGenerator: GenerateAt time is earlier than the previous call, stop generating to avoid generating repeated ID")
 )
 
 // IDGenerator 雪花算法 ID 生成器
 type IDGenerator struct {
-	lastMilli  int64      // 上一次生成 ID 的毫秒时间
-	sequenceID int64      // 本毫秒内的序列号
-	machineID  int64      // 本 IDGenerator 所属机器号
-	IDCID      int64      // 本 IDGenerator 所属 IDC 号
-	mutex      sync.Mutex // 锁，用于并发生成 ID 时不会冲突
+	state              atomic.Int64           // 上一次生成 ID 的毫秒时间与本毫秒内的序列号，打包后原子读写，见 pack
+	lastID             atomic.Int64           // 最近一次生成的 ID，仅开启 WithRandomBits 时记录，见 Last
+	fast               bool                   // 是否可以走无锁快速路径，开启严格校验、重复检测或限流时为 false
+	machineID          int64                  // 本 IDGenerator 所属机器号
+	IDCID              int64                  // 本 IDGenerator 所属 IDC 号
+	regionID           int64                  // 本 IDGenerator 所属区域号，见 WithRegionBits
+	epoch              int64                  // 本 IDGenerator 的开始使用时间，毫秒时间戳
+	clockBackTolerance int64                  // 可容忍的时钟回拨毫秒数，回拨在此范围内时等待时钟追上而不是报错
+	clock              Clock                  // 时间源
+	driftCallback      func(time.Duration)    // 时钟漂移回调，见 WithDriftCallback
+	drift              *driftClock            // 包装 clock 的漂移检测，未开启时为 nil
+	layout             bitLayout              // 各字段的 bit 布局
+	counters           counters               // 运行计数，见 Stats
+	logger             Logger                 // 事件日志，见 WithLogger
+	overflowPolicy     SequenceOverflowPolicy // 同一毫秒内序列号耗尽时的处理策略
+	sequenceRand       *rand.Rand             // 每毫秒序列号起始值的随机源，见 WithRandomizedSequenceStart
+	backoff            Backoff                // 等待时钟前进时的退避策略，见 WithBackoff
+	limiter            *rateLimiter           // 生成速率限制，未开启时为 nil，见 WithRateLimit
+	closed             atomic.Bool            // 是否已关闭，见 Close
+	registered         bool                   // 是否已在进程内登记，见 NewIDGeneratorChecked
+	reservedZero       bool                   // IDC 号和机器号是否保留 0，见 WithReservedZero
+	signed             bool                   // 是否使用符号位作为业务标记位，见 WithSignedMode
+	padded             bool                   // GenerateString 是否补零到固定宽度，见 WithPaddedString
+	strict             bool                   // 是否在每次生成时校验各字段的位数，见 WithStrictChecks
+	duplicates         *duplicateWindow       // 最近生成的 ID，用于重复检测，未开启时为 nil，见 WithDuplicateDetection
+	streamNoRecover    bool                   // 是否关闭 Stream 的 panic 恢复，见 WithoutStreamRecovery
+	persist            io.ReadWriter          // lastMilli 高水位的持久化目标，见 WithPersistence
+	persistWait        bool                   // 构造时高水位晚于当前时钟是否等待
+	persistedMilli     int64                  // 最近一次持久化的高水位，运行中为领先的租约
+	floorPath          string                 // 单调下限的持久化文件，见 WithMonotonicFloor
+	floor              *floorClock            // 单调下限时间源，未开启时为 nil
+	floorPersisted     int64                  // 已持久化的下限租约
+	atMilli            int64                  // GenerateAt 上一次使用的毫秒时间
+	atSequenceID       int64                  // GenerateAt 在 atMilli 内的序列号
+	mutex              sync.Mutex             // 锁，用于并发生成 ID 时不会冲突
 }
 
 // NewIDGenerator 生成一个基于标准雪花算法的 ID 生成器
-func NewIDGenerator(idcID, machineID int64) (*IDGenerator, error) {
-	if idcID > maxIDCID || idcID < 0 {
-		return nil, ErrInvaildIDCID
+func NewIDGenerator(idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	return NewIDGeneratorWithEpoch(idcID, machineID, epoch, opts...)
+}
+
+// NewIDGeneratorWithEpoch 生成一个使用自定义 epoch 的 ID 生成器，epochMilli 为毫秒时间戳，不能晚于当前时间
+func NewIDGeneratorWithEpoch(idcID, machineID, epochMilli int64, opts ...Option) (*IDGenerator, error) {
+	return newIDGeneratorWithRegion(0, idcID, machineID, epochMilli, opts)
+}
+
+// NewIDGeneratorWithRegion 生成一个带区域号的 ID 生成器，区域号位于 IDC 号之上，位数由 WithRegionBits 设置，
+// 未设置 WithRegionBits 时 regionID 只能为 0；区域号越界返回 ErrInvaildRegionID
+func NewIDGeneratorWithRegion(regionID, idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	return newIDGeneratorWithRegion(regionID, idcID, machineID, epoch, opts)
+}
+
+func newIDGeneratorWithRegion(regionID, idcID, machineID, epochMilli int64, opts []Option) (*IDGenerator, error) {
+	g, err := configure(epochMilli, opts)
+	if err != nil {
+		return nil, err
+	}
+	if regionID < 0 || regionID > g.layout.maxRegionID {
+		return nil, ErrInvaildRegionID
+	}
+	g.regionID = regionID
+	if err := g.setNode(idcID, machineID); err != nil {
+		return nil, err
+	}
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// NewIDGeneratorValidated 与 NewIDGeneratorWithEpoch 相同，但会一次性检查 bit 布局、IDC 号、机器号和 epoch，
+// 存在问题时返回汇总了全部问题的 *ValidationError，而不是在第一个问题处返回，便于排查配置
+// bit 布局非法时，IDC 号和机器号仍按各自配置的位数检查；各项检查通过后的初始化错误(如持久化恢复失败)照常直接返回
+func NewIDGeneratorValidated(idcID, machineID, epochMilli int64, opts ...Option) (*IDGenerator, error) {
+	g := applyOptions(epochMilli, opts)
+	var errs []error
+	layout, err := g.layout.rebuild()
+	if err != nil {
+		errs = append(errs, err)
+		// 时间戳位数过大等问题不影响 IDC 号、机器号各自的取值范围
+		layout, err = newLayout(0, g.layout.idcIDBits, g.layout.machineIDBits, 0)
+	}
+	if err == nil {
+		g.layout = layout
+		if err := g.checkIDCID(idcID); err != nil {
+			errs = append(errs, err)
+		}
+		if err := g.checkMachineID(machineID); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if epochMilli > g.now() {
+		errs = append(errs, ErrInvaildEpoch)
+	}
+	if len(errs) > 0 {
+		return nil, &ValidationError{Errs: errs}
+	}
+	g.IDCID, g.machineID = idcID, machineID
+	if err := g.init(); err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+// NewMonotonicGenerator 生成一个优先保证可用与唯一、放弃严格时间准确性的 ID 生成器，适用于时钟经常跳变的虚拟机等环境
+// 生成器维护一个只增不减的逻辑毫秒时间，即 max(系统时钟, 上一次生成 ID 的毫秒)：系统时钟回拨时不报错也不等待，
+// 继续在逻辑毫秒内生成，序列号耗尽后直接推进逻辑毫秒；系统时钟追上逻辑毫秒后恢复使用系统时钟
+// 与 WithMonotonicFloor 不同，逻辑毫秒只保存在内存中，进程重启后仍以系统时钟为准
+// 注意：时钟回拨期间 ID 中的时间戳来自逻辑毫秒，可能晚于真实生成时间；系统时钟早于 epoch 时首次生成仍返回 ErrClockBeforeEpoch
+func NewMonotonicGenerator(idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	g, err := NewIDGenerator(idcID, machineID, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if g.floor == nil {
+		g.floor = &floorClock{clock: g.clock}
+		g.clock = g.floor
+	}
+	return g, nil
+}
+
+// 应用 opts 并计算 bit 布局，得到尚未设置 IDC 号、机器号的生成器
+func configure(epochMilli int64, opts []Option) (*IDGenerator, error) {
+	g := applyOptions(epochMilli, opts)
+	layout, err := g.layout.rebuild()
+	if err != nil {
+		return nil, err
+	}
+	g.layout = layout
+	return g, nil
+}
+
+// 以默认配置为基础应用 opts，此时 layout 中只有各字段的位数和顺序，尚未计算偏移量
+func applyOptions(epochMilli int64, opts []Option) *IDGenerator {
+	g := &IDGenerator{
+		epoch:   epochMilli,
+		atMilli: -1,
+		clock:   systemClock{},
+		layout:  defaultLayout,
+		backoff: GoschedBackoff{},
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	// 应用完所有 Option 后再包装，使漂移检测与 WithClock 的先后顺序无关
+	if g.driftCallback != nil {
+		g.drift = &driftClock{clock: g.clock, base: time.Now(), callback: g.driftCallback}
+		g.clock = g.drift
+	}
+	return g
+}
+
+// 按 bit 布局校验并设置 IDC 号和机器号
+func (g *IDGenerator) setNode(idcID, machineID int64) error {
+	if err := g.checkIDCID(idcID); err != nil {
+		return err
+	}
+	if err := g.checkMachineID(machineID); err != nil {
+		return err
+	}
+	g.IDCID = idcID
+	g.machineID = machineID
+	return nil
+}
+
+func (g *IDGenerator) checkIDCID(idcID int64) error {
+	if idcID > g.layout.maxIDCID || idcID < 0 || g.reservedZero && idcID == 0 {
+		return ErrInvaildIDCID
+	}
+	return nil
+}
+
+func (g *IDGenerator) checkMachineID(machineID int64) error {
+	if machineID > g.layout.maxMachineID || machineID < 0 || g.reservedZero && machineID == 0 {
+		return ErrInvaildMachineID
+	}
+	return nil
+}
+
+// 校验 epoch 并初始化运行状态，需在 setNode 之后调用
+func (g *IDGenerator) init() error {
+	if g.epoch > g.now() {
+		return ErrInvaildEpoch
+	}
+	g.fast = !g.strict && g.duplicates == nil && g.limiter == nil
+	g.state.Store(g.pack(-1, 0))
+	g.lastID.Store(-1)
+	if g.persist != nil {
+		if err := g.restorePersisted(); err != nil {
+			return err
+		}
+	}
+	if g.floorPath != "" {
+		if err := g.restoreFloor(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewIDGeneratorContinuing 基于 prev 的 epoch 生成一个新的 ID 生成器，用于平滑地调整机器号、IDC 号或 bit 布局
+// 新生成器继承 prev 的 lastMilli，并从其下一毫秒开始生成，保证新生成器的第一个 ID 大于 prev 生成过的所有 ID
+// 约束：opts 不能缩小时间戳的偏移量(即 IDC 号、机器号、序列号的总位数)，否则返回 ErrInvaildLayout；
+// 构造后不应再使用 prev 生成 ID
+func NewIDGeneratorContinuing(prev *IDGenerator, idcID, machineID int64, opts ...Option) (*IDGenerator, error) {
+	g, err := NewIDGeneratorWithEpoch(idcID, machineID, prev.epoch, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if g.layout.unixMilliShift < prev.layout.unixMilliShift {
+		return nil, ErrInvaildLayout
+	}
+	prev.mutex.Lock()
+	prevMilli, _ := prev.unpack(prev.state.Load())
+	prev.unlock()
+	if lastMilli, _ := g.unpack(g.state.Load()); prevMilli > lastMilli {
+		g.state.Store(g.pack(prevMilli, g.layout.maxSequenceID))
+	}
+	return g, nil
+}
+
+// Clone 复制本 IDGenerator 的配置(epoch、bit 布局、时间源、日志及各项选项)生成一个使用新机器号的生成器，机器号按同样的规则校验
+// 新生成器的运行状态从头开始，与原生成器不共享任何可变状态；
+// 持久化目标、单调下限文件和进程内登记不会被复制，随机序列号起始值的随机源会以原随机源派生的种子重新创建
+func (g *IDGenerator) Clone(newMachineID int64) (*IDGenerator, error) {
+	g.mutex.Lock()
+	c := &IDGenerator{
+		epoch:              g.epoch,
+		atMilli:            -1,
+		clockBackTolerance: g.clockBackTolerance,
+		clock:              g.clock,
+		logger:             g.logger,
+		layout:             g.layout,
+		regionID:           g.regionID,
+		backoff:            g.backoff,
+		overflowPolicy:     g.overflowPolicy,
+		reservedZero:       g.reservedZero,
+		signed:             g.signed,
+		padded:             g.padded,
+		strict:             g.strict,
+		streamNoRecover:    g.streamNoRecover,
+	}
+	if g.sequenceRand != nil {
+		c.sequenceRand = rand.New(rand.NewSource(g.sequenceRand.Int63()))
+	}
+	if g.limiter != nil {
+		c.limiter = &rateLimiter{rate: g.limiter.rate, lastMilli: -1}
+	}
+	if g.duplicates != nil {
+		WithDuplicateDetection(cap(g.duplicates.ring))(c)
+	}
+	if g.drift != nil {
+		c.drift = g.drift.clone()
+		if g.clock == Clock(g.drift) {
+			c.clock = c.drift
+		}
+	}
+	if g.floor != nil {
+		c.floor = &floorClock{clock: g.floor.clock}
+		if g.drift != nil && g.floor.clock == Clock(g.drift) {
+			c.floor.clock = c.drift
+		}
+		c.floor.floor.Store(g.floor.floor.Load())
+		c.clock = c.floor
 	}
-	if machineID > maxMachineID || machineID < 0 {
-		return nil, ErrInvaildMachineID
+	g.unlock()
+	if err := c.setNode(g.IDCID, newMachineID); err != nil {
+		return nil, err
 	}
-	return &IDGenerator{
-		lastMilli:  -1,
-		sequenceID: 0,
-		machineID:  machineID,
-		IDCID:      idcID,
-	}, nil
+	if err := c.init(); err != nil {
+		return nil, err
+	}
+	return c, nil
 }
 
 // Generate 生成一个 ID
+// 同一毫秒内且序列号未耗尽时通过 CAS 无锁生成，进入新毫秒、序列号耗尽或时钟回拨时才加锁处理
+// 除返回 ClockBackError 等出错情况外不分配堆内存，可用于对分配敏感的热点循环
 func (g *IDGenerator) Generate() (int64, error) {
+	if id, ok := g.generateFast(); ok {
+		return id, nil
+	}
+	return g.generateLocked(context.Background())
+}
+
+// MustGenerate 生成一个 ID，出错时 panic，类似 regexp.MustCompile
+// 仅适用于脚本、测试等生成失败即无法继续的场景；线上请求路径应使用 Generate 并处理 ErrClockBack 等错误
+func (g *IDGenerator) MustGenerate() int64 {
+	id, err := g.Generate()
+	if err != nil {
+		panic(err)
+	}
+	return id
+}
+
+// GenerateContext 生成一个 ID，等待时钟推进(序列号耗尽或容忍范围内的时钟回拨)期间若 ctx 被取消，返回 ctx.Err()
+func (g *IDGenerator) GenerateContext(ctx context.Context) (int64, error) {
+	if id, ok := g.generateFast(); ok {
+		return id, nil
+	}
+	return g.generateLocked(ctx)
+}
+
+// GenerateWaitRollback 生成一个 ID，检测到时钟回拨(now < lastMilli)时最多等待 maxWait 让时钟追上 lastMilli，
+// 不受 WithClockBackTolerance 的容忍范围限制；超时仍未追上则返回 *ClockBackError(可用 errors.Is 判断 ErrClockBack)
+// 等待期间持有锁，其他生成调用会一同阻塞；需要取消等待时请使用 GenerateContext
+func (g *IDGenerator) GenerateWaitRollback(maxWait time.Duration) (int64, error) {
+	if id, ok := g.generateFast(); ok {
+		return id, nil
+	}
+	if g.limiter != nil && !g.limiter.allow(g.now()) {
+		return -1, ErrRateLimited
+	}
+	g.mutex.Lock()
+	defer g.unlock()
+	if g.closed.Load() {
+		return -1, ErrClosed
+	}
+	lastMilli, _ := g.unpack(g.state.Load())
+	if now := g.now(); now >= g.epoch && now < lastMilli {
+		g.clockBack(lastMilli, now)
+		deadline := time.Now().Add(maxWait)
+		for now < lastMilli {
+			if !time.Now().Before(deadline) {
+				return -1, &ClockBackError{LastMilli: lastMilli, NowMilli: now}
+			}
+			runtime.Gosched()
+			now = g.now()
+		}
+	}
+	return g.generate(context.Background())
+}
+
+// GenerateRetry 生成一个 ID，遇到时钟回拨(errors.Is(err, ErrClockBack))时休眠 delay 后重试，最多重试 attempts 次
+// 成功或遇到其他错误时立即返回；重试次数用完仍回拨时返回最后一次的错误。休眠期间不持有锁
+func (g *IDGenerator) GenerateRetry(attempts int, delay time.Duration) (int64, error) {
+	id, err := g.Generate()
+	for i := 0; i < attempts && errors.Is(err, ErrClockBack); i++ {
+		time.Sleep(delay)
+		id, err = g.Generate()
+	}
+	return id, err
+}
+
+// GenerateWithFlag 生成一个 ID，并将原本不使用的最高位(符号位)作为业务标记位，可用于区分两类 ID
+// flag 为 true 时返回的 ID 是负数；Decompose、TimestampOf 会忽略该位，FlagOf 可读取该位
+// 注意：带标记的 ID 按 int64 比较时小于所有不带标记的 ID，不再与生成时间保持一致的顺序
+func (g *IDGenerator) GenerateWithFlag(flag bool) (int64, error) {
+	id, err := g.Generate()
+	if err != nil || !flag {
+		return id, err
+	}
+	return id | flagBit, nil
+}
+
+// GenerateString 生成一个 ID 并返回其十进制字符串形式，出错时返回空字符串
+// 开启 WithPaddedString 时左侧补零到固定宽度
+func (g *IDGenerator) GenerateString() (string, error) {
+	id, err := g.Generate()
+	if err != nil {
+		return "", err
+	}
+	str := strconv.FormatInt(id, 10)
+	if g.padded {
+		if width := g.layout.decimalWidth(); len(str) < width {
+			str = strings.Repeat("0", width-len(str)) + str
+		}
+	}
+	return str, nil
+}
+
+// GenerateUint64 生成一个 ID 并返回其 uint64 形式，适用于以 uint64 存储 ID 的场景
+// 两种形式的 64 位完全相同，只是解释方式不同：正常生成的 ID 符号位为 0，uint64(id) 与 id 数值相等；
+// GenerateWithFlag 置位的最高位在 uint64 形式下表现为 >= 1<<63 的值而不是负数。int64(u) 可无损转换回来
+func (g *IDGenerator) GenerateUint64() (uint64, error) {
+	id, err := g.Generate()
+	if err != nil {
+		return 0, err
+	}
+	return uint64(id), nil
+}
+
+// GenerateSpaced 生成一个 ID，并保证其毫秒时间晚于上一个 ID，序列号为 0
+// 每次调用都会等待进入新的毫秒，因此单个生成器通过该方法每毫秒最多生成一个 ID，
+// 吞吐量上限约为每秒 1000 个，适用于希望 ID 按毫秒粗粒度分桶而非挤在同一毫秒内的场景
+func (g *IDGenerator) GenerateSpaced() (int64, error) {
+	if g.limiter != nil && !g.limiter.allow(g.now()) {
+		return -1, ErrRateLimited
+	}
+	g.mutex.Lock()
+	defer g.unlock()
+	ctx := context.Background()
+	for {
+		state := g.state.Load()
+		lastMilli, _ := g.unpack(state)
+		now, err := g.currentMilli(ctx, lastMilli)
+		if err != nil {
+			return -1, err
+		}
+		if now == lastMilli {
+			if now, err = g.tilNextMilli(ctx, now, lastMilli); err != nil {
+				return -1, err
+			}
+		}
+		if id, ok, err := g.commit(state, lastMilli, now, 0); err != nil || ok {
+			return id, err
+		}
+	}
+}
+
+// GenerateAt 使用指定的时间而非当前时钟生成 ID，用于为历史数据回填时间正确的 ID
+// GenerateAt 使用独立于 Generate 的序列号，不影响实时生成的 lastMilli；连续对同一毫秒调用时序列号递增，
+// 该毫秒序列号耗尽时返回 ErrSequenceExhausted，t 早于 epoch 时返回 ErrInvaildTime
+// 只记录最近一次调用的毫秒，t 早于上一次调用的毫秒时返回 ErrAtTimeBack，因此必须按时间非递减的顺序回填；
+// 注意：与实时生成的 ID 落在同一毫秒时可能重复，回填建议使用单独的机器号
+func (g *IDGenerator) GenerateAt(t time.Time) (int64, error) {
+	milli := t.UnixMilli()
+	if milli < g.epoch {
+		return -1, ErrInvaildTime
+	}
+	if milli-g.epoch > g.layout.maxTimestamp {
+		return -1, ErrTimestampOverflow
+	}
 	g.mutex.Lock()
-	defer g.mutex.Unlock()
+	defer g.unlock()
+	if g.closed.Load() {
+		return -1, ErrClosed
+	}
+	if milli < g.atMilli {
+		return -1, ErrAtTimeBack
+	}
+	if milli == g.atMilli {
+		if g.atSequenceID >= g.layout.maxSequenceID {
+			return -1, ErrSequenceExhausted
+		}
+		g.atSequenceID++
+	} else {
+		g.atMilli = milli
+		g.atSequenceID = 0
+	}
+	g.counters.generated.Add(1)
+	return g.compose(milli, g.atSequenceID), nil
+}
+
+// GenerateWithSequence 使用当前毫秒和调用方指定的序列号拼接 ID，用于分片导入等需要确定性序列号的场景
+// 这是绕过内部计数的高级用法：不读写 lastMilli 与序列号，也不做时钟回拨检测，ID 的唯一性完全由调用方保证，
+// 与 Generate 混用时可能生成重复 ID；seq 超出 [0, MaxSequenceID] 时返回 ErrInvaildSequenceID
+func (g *IDGenerator) GenerateWithSequence(seq int64) (int64, error) {
+	if g.closed.Load() {
+		return -1, ErrClosed
+	}
 	now := g.now()
+	if now < g.epoch {
+		return -1, ErrClockBeforeEpoch
+	}
+	id, err := g.layout.composeChecked(now-g.epoch, g.regionID, g.IDCID, g.machineID, seq)
+	if err != nil {
+		return -1, err
+	}
+	g.counters.generated.Add(1)
+	return id, nil
+}
+
+// GenerateN 一次加锁批量生成 n 个 ID，返回的 ID 严格递增
+// 批量生成过程中若检测到时钟回拨，返回已生成的 ID 以及对应的错误
+func (g *IDGenerator) GenerateN(n int) ([]int64, error) {
+	if n <= 0 {
+		return nil, nil
+	}
+	// 按毫秒整段占用序列号，每段只需一次 CAS；严格校验与重复检测需要逐个检查每个 ID
+	if g.fast {
+		_, ids, err := g.Reserve(n)
+		return ids, err
+	}
+	if g.limiter != nil && !g.limiter.allowN(g.now(), int64(n)) {
+		return nil, ErrRateLimited
+	}
+	g.mutex.Lock()
+	defer g.unlock()
+	ids := make([]int64, 0, n)
+	for i := 0; i < n; i++ {
+		id, err := g.generate(context.Background())
+		if err != nil {
+			return ids, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+// Reserve 一次性预留 k 个连续的 ID，start 为其中第一个 ID
+// 预留过程持有锁，并按毫秒整段占用序列号：跨毫秒时先占满当前毫秒剩余的序列号，再从下一毫秒的起始序列号继续，
+// 因此预留区间内不会穿插其他并发调用生成的 ID；中途出错时返回已预留的部分 ID 以及对应的错误
+// 开启 ReturnError 策略时不等待下一毫秒，当前毫秒的序列号用完即返回已预留的部分 ID 与 ErrSequenceExhausted
+func (g *IDGenerator) Reserve(k int) (start int64, ids []int64, err error) {
+	if k <= 0 {
+		return -1, nil, nil
+	}
+	if g.limiter != nil && !g.limiter.allowN(g.now(), int64(k)) {
+		return -1, nil, ErrRateLimited
+	}
+	g.mutex.Lock()
+	defer g.unlock()
+	// 中途出错时同样按实际返回的 ID 更新计数与 Last
+	defer func() {
+		if len(ids) > 0 {
+			g.counters.generated.Add(int64(len(ids)))
+			g.recordLast(ids[len(ids)-1])
+		}
+	}()
+	ctx := context.Background()
+	ids = make([]int64, 0, k)
+	for len(ids) < k {
+		state := g.state.Load()
+		lastMilli, sequenceID := g.unpack(state)
+		now, err := g.currentMilli(ctx, lastMilli)
+		if err != nil {
+			return firstOf(ids), ids, err
+		}
+		first := sequenceID + 1
+		if now == lastMilli && sequenceID >= g.layout.maxSequenceID {
+			if g.overflowPolicy == ReturnError {
+				return firstOf(ids), ids, ErrSequenceExhausted
+			}
+			g.sequenceExhausted(lastMilli)
+			if now, err = g.tilNextMilli(ctx, now, lastMilli); err != nil {
+				return firstOf(ids), ids, err
+			}
+		}
+		if now != lastMilli {
+			first = g.startSequence()
+		}
+		last := first + int64(k-len(ids)) - 1
+		if last > g.layout.maxSequenceID {
+			last = g.layout.maxSequenceID
+		}
+		ok, err := g.claim(state, lastMilli, now, last)
+		if err != nil {
+			return firstOf(ids), ids, err
+		}
+		if !ok {
+			continue
+		}
+		// 同一段内只有序列号不同，校验首尾两个序列号即可覆盖整段
+		if g.strict {
+			for _, seq := range [...]int64{first, last} {
+				if err := g.layout.check(now-g.epoch, g.regionID, g.IDCID, g.machineID, seq); err != nil {
+					return firstOf(ids), ids, err
+				}
+			}
+		}
+		for seq := first; seq <= last; seq++ {
+			id := g.compose(now, seq)
+			if g.duplicates != nil && !g.duplicates.add(id) {
+				return firstOf(ids), ids, ErrDuplicateDetected
+			}
+			ids = append(ids, id)
+		}
+	}
+	return ids[0], ids, nil
+}
+
+func firstOf(ids []int64) int64 {
+	if len(ids) == 0 {
+		return -1
+	}
+	return ids[0]
+}
+
+// RemainingThisMilli 返回当前毫秒内在序列号耗尽、需要等待下一毫秒之前还能生成的 ID 数
+// 当前毫秒尚未生成过 ID 时按新毫秒可用的序列号范围计算：默认为 MaxSequenceID+1，
+// 开启 WithRandomizedSequenceStart 时起始序列号随机，返回起始值最大时仍能生成的数量
+// 结果只是读取时的快照，可供调用方在接近耗尽前主动退避
+func (g *IDGenerator) RemainingThisMilli() int64 {
+	g.mutex.Lock()
+	defer g.unlock()
+	lastMilli, sequenceID := g.unpack(g.state.Load())
+	if g.now() == lastMilli {
+		return g.layout.maxSequenceID - sequenceID
+	}
+	var maxStart int64
+	if g.sequenceRand != nil {
+		maxStart = g.layout.maxSequenceID / 2
+	}
+	return g.layout.maxSequenceID - maxStart + 1
+}
+
+// Peek 返回按当前时钟下一次 Generate 将会生成的 ID，但不消耗该 ID，也不修改 lastMilli 与序列号，用于调试
+// 结果仅供参考：调用 Peek 与随后的 Generate 之间时钟可能前进，其他调用方也可能先生成 ID；
+// 开启 WithRandomizedSequenceStart 时无法预知新毫秒的起始序列号，按 0 计算；开启 WithRandomBits 时随机部分每次重新生成，与随后生成的 ID 不同
+// 时钟回拨在容忍范围内时按等待后的结果计算；序列号已用完时返回按下一毫秒计算的 ID，开启 ReturnError 策略时返回 ErrSequenceExhausted
+func (g *IDGenerator) Peek() (int64, error) {
+	g.mutex.Lock()
+	defer g.unlock()
+	if g.closed.Load() {
+		return -1, ErrClosed
+	}
+	lastMilli, sequenceID := g.unpack(g.state.Load())
+	now := g.now()
+	if now < g.epoch {
+		return -1, ErrClockBeforeEpoch
+	}
+	if now < lastMilli {
+		if lastMilli-now > g.clockBackTolerance {
+			return -1, &ClockBackError{LastMilli: lastMilli, NowMilli: now}
+		}
+		now = lastMilli
+	}
+	milli, sequenceID, _ := g.nextSlot(lastMilli, sequenceID, now)
+	if milli > now && g.overflowPolicy == ReturnError {
+		return -1, ErrSequenceExhausted
+	}
+	if milli-g.epoch > g.layout.maxTimestamp {
+		return -1, ErrTimestampOverflow
+	}
+	return g.compose(milli, sequenceID), nil
+}
+
+// Last 返回最近一次生成的 ID 而不消耗新的 ID，尚未生成过 ID 时 ok 为 false
+// ID 由记录的 lastMilli 与序列号重新拼接得到，不包含 GenerateWithFlag 的标记位，也不反映 GenerateAt 的生成结果；
+// Reserve 预留的 ID 段视为已生成，此时返回段内最后一个 ID；
+// 通过 WithPersistence、NewIDGeneratorContinuing 恢复的高水位同样视为已生成，返回的 ID 可能并未真正发出；
+// 开启 WithRandomBits 时随机部分无法重新拼接，改为返回记录的最近一次生成的 ID，恢复的高水位不计入
+func (g *IDGenerator) Last() (id int64, ok bool) {
+	g.mutex.Lock()
+	defer g.unlock()
+	if g.layout.randomBits > 0 {
+		id := g.lastID.Load()
+		return id, id != -1
+	}
+	lastMilli, sequenceID := g.unpack(g.state.Load())
+	if lastMilli == -1 {
+		return -1, false
+	}
+	return g.compose(lastMilli, sequenceID), true
+}
+
+// LastMilli 返回最近一次生成 ID 的毫秒时间，尚未生成过 ID 时返回 -1
+// lastMilli 与序列号打包在同一个原子变量中，由生成路径每次 CAS 整体更新，这里直接原子读取而不加锁，
+// 不会读到更新到一半的值，也不会与 Generate 争抢锁，适合高频监控采样
+func (g *IDGenerator) LastMilli() int64 {
+	lastMilli, _ := g.unpack(g.state.Load())
+	return lastMilli
+}
+
+// MillisForBurst 估算连续生成 n 个 ID 会跨越的毫秒数，计入当前毫秒已用掉的序列号，n <= 0 时返回 0
+// 估算基于调用时的状态，开启 WithRandomizedSequenceStart 时每毫秒可用的序列号更少，实际耗时可能更长
+func (g *IDGenerator) MillisForBurst(n int) int64 {
+	if n <= 0 {
+		return 0
+	}
+	perMilli := g.layout.maxSequenceID + 1
+	remaining, left := g.RemainingThisMilli(), int64(n)
+	var millis int64
+	if remaining > 0 {
+		millis = 1
+		if left <= remaining {
+			return millis
+		}
+		left -= remaining
+	}
+	return millis + (left+perMilli-1)/perMilli
+}
+
+// Close 关闭生成器：持久化最新的 lastMilli 高水位，释放 NewIDGeneratorChecked 的登记，
+// 之后的生成调用均返回 ErrClosed，Stream 启动的 goroutine 也会因此结束
+// Close 可重复调用，与正在进行的生成调用并发也是安全的，重复调用返回 nil
+func (g *IDGenerator) Close() error {
+	g.mutex.Lock()
+	defer g.unlock()
+	if g.closed.Swap(true) {
+		return nil
+	}
+	g.unregister()
+	runtime.SetFinalizer(g, nil)
+	// 关闭后不再生成 ID，用实际的 lastMilli 替换领先的租约，使重启时无需等待
+	if lastMilli, _ := g.unpack(g.state.Load()); g.persist != nil && lastMilli >= 0 && lastMilli != g.persistedMilli {
+		return g.writePersisted(lastMilli)
+	}
+	return nil
+}
+
+// Reset 将生成器的运行状态恢复为刚构造时的状态(lastMilli 为 -1，序列号为 0)，不影响机器号、IDC 号等配置
+// 注意：Reset 后同一毫秒内的序列号会从 0 重新开始，若当前毫秒已经生成过 ID，可能生成重复 ID
+func (g *IDGenerator) Reset() {
+	g.mutex.Lock()
+	defer g.unlock()
+	g.state.Store(g.pack(-1, 0))
+	g.lastID.Store(-1)
+}
+
+// AcceptClockBack 确认时钟回拨是安全的校正，将 lastMilli 重置为当前时间，使因回拨返回 ErrClockBack 的生成器无需重启即可恢复
+// 序列号置为最大值，下一个 ID 从当前时间的下一毫秒开始
+// 注意：回拨区间内的毫秒若已生成过 ID，恢复后会再次生成这些毫秒的 ID，可能与之前的 ID 重复，
+// 只应在确认回拨前没有其他生成器使用过这些毫秒，或确认重复无害时调用
+func (g *IDGenerator) AcceptClockBack() {
+	g.mutex.Lock()
+	defer g.unlock()
+	if now := g.now(); now >= g.epoch {
+		g.state.Store(g.pack(now, g.layout.maxSequenceID))
+		return
+	}
+	g.state.Store(g.pack(-1, 0))
+}
+
+// SetFloor 将 lastMilli 设为 milli 并视该毫秒的序列号已用完，之后生成的 ID 时间戳均晚于 milli，
+// 用于测试中确定性地触发等待与时钟回拨分支，或迁移时跳过旧生成器可能用过的时间段
+// 时钟早于 milli 时生成行为与时钟回拨相同：回拨在 WithClockBackTolerance 范围内时等待，否则返回 ErrClockBack
+// milli 早于 epoch 时返回 ErrInvaildTime，超出时间戳位数时返回 ErrTimestampOverflow；milli 早于当前 lastMilli 时同样会回退状态，可能生成重复 ID
+func (g *IDGenerator) SetFloor(milli int64) error {
+	if milli < g.epoch {
+		return ErrInvaildTime
+	}
+	if milli-g.epoch > g.layout.maxTimestamp {
+		return ErrTimestampOverflow
+	}
+	g.mutex.Lock()
+	defer g.unlock()
+	g.state.Store(g.pack(milli, g.layout.maxSequenceID))
+	return nil
+}
+
+// 无锁快速路径：当前毫秒与上一次生成 ID 的毫秒相同且序列号未耗尽时，CAS 递增序列号
+// 其余情况以及 fast 为 false 时返回 false，由调用方通过 generateLocked 加锁处理
+// 这是最热的路径，新毫秒、序列号耗尽、时钟回拨和各项可选检查都放在 generateLocked 中，保持这里的分支尽量少
+func (g *IDGenerator) generateFast() (int64, bool) {
+	if !g.fast || g.closed.Load() {
+		return -1, false
+	}
+	now := g.now()
+	for {
+		state := g.state.Load()
+		lastMilli, sequenceID := g.unpack(state)
+		if now != lastMilli || sequenceID >= g.layout.maxSequenceID {
+			return -1, false
+		}
+		// 同一毫秒内 state 加一即序列号加一
+		if g.state.CompareAndSwap(state, state+1) {
+			g.counters.generated.Add(1)
+			id := g.compose(now, sequenceID+1)
+			g.recordLast(id)
+			return id, true
+		}
+	}
+}
+
+// 慢速路径：限流检查通过后加锁生成，与 generateFast 分开，使 Generate 等入口的快速路径上不包含 defer 与加锁
+func (g *IDGenerator) generateLocked(ctx context.Context) (int64, error) {
+	if g.limiter != nil && !g.limiter.allow(g.now()) {
+		return -1, ErrRateLimited
+	}
+	g.mutex.Lock()
+	defer g.unlock()
+	return g.generate(ctx)
+}
+
+// 生成一个 ID，调用方需持有锁
+// 持锁期间快速路径仍可能修改 state，因此基于读取到的 state 计算后通过 CAS 写回，失败则重试
+func (g *IDGenerator) generate(ctx context.Context) (int64, error) {
+	for {
+		state := g.state.Load()
+		lastMilli, sequenceID := g.unpack(state)
+		now, err := g.currentMilli(ctx, lastMilli)
+		if err != nil {
+			return -1, err
+		}
+		milli, sequenceID, fresh := g.nextSlot(lastMilli, sequenceID, now)
+		// 若同一毫秒内序列号已经用完，则等待到下一毫秒，或按策略直接返回错误
+		if milli > now {
+			if g.overflowPolicy == ReturnError {
+				return -1, ErrSequenceExhausted
+			}
+			g.sequenceExhausted(lastMilli)
+			if now, err = g.tilNextMilli(ctx, now, lastMilli); err != nil {
+				return -1, err
+			}
+		}
+		if fresh {
+			sequenceID = g.startSequence()
+		}
+		if id, ok, err := g.commit(state, lastMilli, now, sequenceID); err != nil || ok {
+			return id, err
+		}
+	}
+}
+
+// 根据上一次生成的 lastMilli、sequenceID 和不早于 lastMilli 的当前时间 now，计算下一个 ID 使用的毫秒时间和序列号
+// 当毫秒时间相等时，序列号加一即可；当 now 已经超过 lastMilli，或同一毫秒内序列号已经用完时，
+// 进入新的毫秒，fresh 为 true，序列号应替换为 startSequence 的起始值；序列号用完时返回的 milli 为 lastMilli+1，晚于 now
+// 只做计算，不读写任何状态
+func (g *IDGenerator) nextSlot(lastMilli, sequenceID, now int64) (milli, nextSequenceID int64, fresh bool) {
+	if now != lastMilli {
+		return now, 0, true
+	}
+	if sequenceID < g.layout.maxSequenceID {
+		return lastMilli, sequenceID + 1, false
+	}
+	return lastMilli + 1, 0, true
+}
+
+// 获取当前毫秒时间并处理时钟回拨，返回的时间不早于 lastMilli，调用方需持有锁
+func (g *IDGenerator) currentMilli(ctx context.Context, lastMilli int64) (int64, error) {
+	if g.closed.Load() {
+		return -1, ErrClosed
+	}
+	now := g.now()
+	// 时钟早于 epoch 时 now-epoch 为负数，拼接出的时间戳字段没有意义，且 pack 无法表示 now，
+	// 若继续生成会让每次调用都被当作初始状态，同一毫秒内反复从序列号 0 开始生成重复 ID
+	if now < g.epoch {
+		g.counters.clockBackEvents.Add(1)
+		if g.logger != nil {
+			g.logger.Log(EventClockBeforeEpoch, map[string]any{"epoch": g.epoch, "now_milli": now})
+		}
+		return -1, ErrClockBeforeEpoch
+	}
 	// 机器时钟回拨才会导致 now 当前毫秒时间戳小于上一次生成 ID 的毫秒时间戳
-	if now < g.lastMilli {
-		return -1, ErrClockBack
+	// 回拨幅度在容忍范围内时，等待时钟追上 lastMilli 后继续
+	if now < lastMilli {
+		g.clockBack(lastMilli, now)
+		if lastMilli-now > g.clockBackTolerance {
+			return -1, &ClockBackError{LastMilli: lastMilli, NowMilli: now}
+		}
+		return g.tilMilli(ctx, now, lastMilli)
+	}
+	return now, nil
+}
+
+// 记录一次时钟回拨
+func (g *IDGenerator) clockBack(lastMilli, now int64) {
+	g.counters.clockBackEvents.Add(1)
+	if g.logger != nil {
+		g.logger.Log(EventClockBack, map[string]any{"last_milli": lastMilli, "now_milli": now})
 	}
-	// 当毫秒时间相等时，改变序列号即可，顺便考虑下序列号超了的情况
-	// 当 now 当前毫秒时间戳已经超过上一次生成 ID 当毫秒时间戳，重置 seqID
-	if now == g.lastMilli {
-		g.sequenceID++
-		if g.sequenceID > maxSequenceID {
-			// 若同一毫秒内序列号已经超了，则等待到下一毫秒并且重置 seqID
-			now = g.tilNextMilli(now)
-			g.sequenceID = 0
+}
+
+// 记录一次序列号耗尽后的等待
+func (g *IDGenerator) sequenceExhausted(milli int64) {
+	g.counters.sequenceWaits.Add(1)
+	if g.logger != nil {
+		g.logger.Log(EventSequenceExhausted, map[string]any{"milli": milli})
+	}
+}
+
+// 新毫秒的起始序列号，默认为 0，开启 WithRandomizedSequenceStart 时为 [0, (maxSequenceID+1)/2) 内的随机值，调用方需持有锁
+func (g *IDGenerator) startSequence() int64 {
+	if g.sequenceRand == nil {
+		return 0
+	}
+	return g.sequenceRand.Int63n(g.layout.maxSequenceID/2 + 1)
+}
+
+// 将 state 从读取时的值更新为 (now, sequenceID) 并拼接 ID，调用方需持有锁
+// CAS 失败说明快速路径修改了 state，返回 false 由调用方重试
+func (g *IDGenerator) commit(state, lastMilli, now, sequenceID int64) (int64, bool, error) {
+	if ok, err := g.claim(state, lastMilli, now, sequenceID); !ok {
+		return -1, false, err
+	}
+	if g.strict {
+		if err := g.layout.check(now-g.epoch, g.regionID, g.IDCID, g.machineID, sequenceID); err != nil {
+			return -1, true, err
+		}
+	}
+	id := g.compose(now, sequenceID)
+	if g.duplicates != nil && !g.duplicates.add(id) {
+		return -1, true, ErrDuplicateDetected
+	}
+	g.counters.generated.Add(1)
+	g.recordLast(id)
+	return id, true, nil
+}
+
+// 开启 WithRandomBits 时记录最近一次生成的 ID，随机部分无法由 state 重新拼接得到
+func (g *IDGenerator) recordLast(id int64) {
+	if g.layout.randomBits > 0 {
+		g.lastID.Store(id)
+	}
+}
+
+// 将 state 从读取时的值更新为 (now, last)，即占用 now 毫秒内直到 last 的序列号，调用方需持有锁
+// 进入新毫秒时先检查时间戳是否超出时间戳位数并按需持久化高水位；CAS 失败返回 false
+func (g *IDGenerator) claim(state, lastMilli, now, last int64) (bool, error) {
+	if now != lastMilli {
+		// 超出时间戳位数后继续拼接会覆盖符号位甚至丢失高位，生成错误的 ID
+		if now-g.epoch > g.layout.maxTimestamp {
+			if g.logger != nil {
+				g.logger.Log(EventTimestampOverflow, map[string]any{"now_milli": now, "epoch": g.epoch})
+			}
+			return false, ErrTimestampOverflow
+		}
+		if err := g.flushPersisted(now); err != nil {
+			return false, err
 		}
-	} else if now > g.lastMilli {
-		g.sequenceID = 0
+		if err := g.flushFloor(now); err != nil {
+			return false, err
+		}
+	}
+	if !g.state.CompareAndSwap(state, g.pack(now, last)) {
+		return false, nil
+	}
+	return true, nil
+}
+
+// 将毫秒时间与序列号打包为一个 int64：相对 epoch 的毫秒数左移序列号位数后与序列号拼接
+// 尚未生成过 ID(lastMilli 为 -1)时打包为 -1
+// 同一毫秒内序列号加一恰好等于打包值加一，快速路径据此直接 CAS
+func (g *IDGenerator) pack(lastMilli, sequenceID int64) int64 {
+	if lastMilli < 0 {
+		return -1
+	}
+	return (lastMilli-g.epoch)<<g.layout.sequenceIDBits | sequenceID
+}
+
+// pack 的逆操作
+func (g *IDGenerator) unpack(state int64) (lastMilli, sequenceID int64) {
+	if state < 0 {
+		return -1, 0
+	}
+	return state>>g.layout.sequenceIDBits + g.epoch, state & g.layout.maxSequenceID
+}
+
+// 按本 IDGenerator 的配置拼接 ID
+func (g *IDGenerator) compose(now, sequenceID int64) int64 {
+	var random int64
+	if g.layout.randomBits > 0 {
+		random = cryptoRandom(g.layout.maxRandom)
 	}
-	g.lastMilli = now
-	return (now-epoch)<<unixMilliShift | g.IDCID<<idcIDShift | g.machineID<<machineIDShift | g.sequenceID, nil
+	return g.layout.composeAll(now-g.epoch, g.regionID, g.IDCID, g.machineID, sequenceID, random)
+}
+
+// Decompose 按本 IDGenerator 的 epoch 和 bit 布局反解 ID，语义同包级函数 Decompose
+func (g *IDGenerator) Decompose(id int64) (timestampMilli, idcID, machineID, sequenceID int64) {
+	timestampMilli, idcID, machineID, sequenceID = g.layout.decompose(id)
+	timestampMilli += g.epoch
+	return
+}
+
+// DecomposeRegion 同 Decompose，同时返回 ID 中的区域号，未开启 WithRegionBits 时区域号恒为 0
+func (g *IDGenerator) DecomposeRegion(id int64) (timestampMilli, regionID, idcID, machineID, sequenceID int64) {
+	timestampMilli, idcID, machineID, sequenceID = g.Decompose(id)
+	return timestampMilli, g.layout.region(id), idcID, machineID, sequenceID
+}
+
+// RegionID 返回本 IDGenerator 的区域号
+func (g *IDGenerator) RegionID() int64 {
+	return g.regionID
+}
+
+// Compose 是 Decompose 的逆操作，按本 IDGenerator 的 epoch 和 bit 布局拼接 ID
+// 与包级函数 Compose 截断越界字段不同，任一字段超出布局范围时返回对应的错误：
+// IDC 号、机器号、序列号越界分别返回 ErrInvaildIDCID、ErrInvaildMachineID、ErrInvaildSequenceID，
+// 时间早于 epoch 返回 ErrInvaildTime，超出时间戳位数返回 ErrTimestampOverflow
+func (g *IDGenerator) Compose(timestampMilli, idcID, machineID, sequenceID int64) (int64, error) {
+	return g.layout.composeChecked(timestampMilli-g.epoch, g.regionID, idcID, machineID, sequenceID)
+}
+
+// TimestampOf 按本 IDGenerator 的 epoch 和 bit 布局返回 ID 的生成时间
+func (g *IDGenerator) TimestampOf(id int64) time.Time {
+	timestampMilli, _, _, _ := g.Decompose(id)
+	return time.UnixMilli(timestampMilli)
+}
+
+// IsValid 粗略校验外部传入的 ID 是否可能由本 IDGenerator 的配置生成，用于在接口边界过滤伪造或损坏的 ID
+// 符号位被置位(除非通过 WithSignedMode 开启了标记位)、布局之外的高位不为 0、时间戳晚于当前时间的 ID 均视为非法
+func (g *IDGenerator) IsValid(id int64) bool {
+	if id < 0 {
+		if !g.signed {
+			return false
+		}
+		id &= maxID
+	}
+	if id>>g.layout.totalBits != 0 {
+		return false
+	}
+	if g.layout.checksum && !VerifyChecksum(id) {
+		return false
+	}
+	timestampMilli, _, _, _ := g.Decompose(id)
+	return timestampMilli <= g.now()
+}
+
+// Epoch 返回本 IDGenerator 使用的 epoch 毫秒时间戳，解析其生成的 ID 时应使用同一 epoch
+func (g *IDGenerator) Epoch() int64 {
+	return g.epoch
+}
+
+// Layout 返回本 IDGenerator 的时间戳、IDC 号、机器号、序列号各自占用的 bit 位数
+// 开启 WithRegionBits 时区域号的位数不在其中，见 RegionBits
+func (g *IDGenerator) Layout() (timestampBits, idcBits, machineBits, sequenceBits int) {
+	return g.layout.bits()
+}
+
+// RegionBits 返回本 IDGenerator 的区域号占用的 bit 位数，未开启 WithRegionBits 时为 0
+// 与 Layout 返回的四个位数之和即 ID 使用的总位数
+func (g *IDGenerator) RegionBits() int {
+	return g.layout.regionBits
+}
+
+// MaxMachineID 返回本 IDGenerator 的 bit 布局下机器号的最大值
+func (g *IDGenerator) MaxMachineID() int64 {
+	return g.layout.maxMachineID
+}
+
+// MaxIDCID 返回本 IDGenerator 的 bit 布局下 IDC 号的最大值
+func (g *IDGenerator) MaxIDCID() int64 {
+	return g.layout.maxIDCID
+}
+
+// MaxSequenceID 返回本 IDGenerator 的 bit 布局下每毫秒序列号的最大值
+func (g *IDGenerator) MaxSequenceID() int64 {
+	return g.layout.maxSequenceID
+}
+
+// CapacityPerSecond 返回单个生成器每秒最多能生成的 ID 数，默认布局下为 4096 * 1000
+func (g *IDGenerator) CapacityPerSecond() int64 {
+	return g.CapacityOver(time.Second)
+}
+
+// CapacityOver 返回单个生成器在 d 时长内最多能生成的 ID 数，不足一毫秒的部分不计
+func (g *IDGenerator) CapacityOver(d time.Duration) int64 {
+	return (g.layout.maxSequenceID + 1) * d.Milliseconds()
+}
+
+// TimeUntilExhaustion 返回距时间戳字段用尽(now-epoch 超过时间戳位数能表示的最大值，此后 Generate 返回 ErrTimestampOverflow)还有多久
+// 默认 41 位时间戳从 epoch 起约可使用 69 年；已经用尽时返回 0，超出 time.Duration 的表示范围(约 292 年)时返回其最大值
+func (g *IDGenerator) TimeUntilExhaustion() time.Duration {
+	remaining := g.layout.maxTimestamp - (g.now() - g.epoch) + 1
+	switch {
+	case remaining <= 0:
+		return 0
+	case remaining > math.MaxInt64/int64(time.Millisecond):
+		return math.MaxInt64
+	}
+	return time.Duration(remaining) * time.Millisecond
 }
 
 // 获取当前的毫秒时间戳
 func (g *IDGenerator) now() int64 {
-	return time.Now().UnixMilli()
+	return g.clock.NowMilli()
+}
+
+// 释放锁，并在锁外报告持锁期间检测到的时钟漂移，见 WithDriftCallback
+func (g *IDGenerator) unlock() {
+	g.mutex.Unlock()
+	if g.drift != nil {
+		g.drift.report()
+	}
 }
 
-// 等待到下一毫秒
-func (g *IDGenerator) tilNextMilli(now int64) int64 {
-	for now <= g.lastMilli {
+// 等待到 lastMilli 的下一毫秒
+func (g *IDGenerator) tilNextMilli(ctx context.Context, now, lastMilli int64) (int64, error) {
+	return g.tilMilli(ctx, now, lastMilli+1)
+}
+
+// 等待到时钟不早于 target 毫秒，等待期间 ctx 被取消则返回 ctx.Err()
+// 每次检查时钟前按 WithBackoff 设置的策略退避，默认通过 runtime.Gosched 让出 CPU，避免空转占满一个核而饿死其他 goroutine
+func (g *IDGenerator) tilMilli(ctx context.Context, now, target int64) (int64, error) {
+	done, since := ctx.Done(), now
+	for now < target {
+		if g.floor != nil && g.floor.advance(target) {
+			return target, nil
+		}
+		if done != nil {
+			select {
+			case <-done:
+				return now, ctx.Err()
+			default:
+			}
+		}
+		g.backoff.Wait(since)
 		now = g.now()
 	}
-	return now
+	return now, nil
 }
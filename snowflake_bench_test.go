@@ -0,0 +1,137 @@
+package snowflake
+
+import (
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const batchSize = 256
+
+// 默认布局每毫秒只有 4096 个序列号，生成速度很快会被真实时钟限制；
+// 加宽序列号使每毫秒的容量远超生成速度，基准测试衡量的是生成本身的开销
+var wideSequence = []Option{WithIDCBits(1), WithMachineBits(1), WithSequenceBits(20)}
+
+func newBenchGenerator(b *testing.B, opts ...Option) *IDGenerator {
+	b.Helper()
+	g, err := NewIDGenerator(1, 1, opts...)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return g
+}
+
+// 与 BenchmarkGenerateN 对照：逐个调用 Generate 生成 batchSize 个 ID
+func BenchmarkGenerateLoop(b *testing.B) {
+	g := newBenchGenerator(b, wideSequence...)
+	for i := 0; i < b.N; i++ {
+		for j := 0; j < batchSize; j++ {
+			if _, err := g.Generate(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkGenerateN(b *testing.B) {
+	g := newBenchGenerator(b, wideSequence...)
+	for i := 0; i < b.N; i++ {
+		if _, err := g.GenerateN(batchSize); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 对照 CAS 快速路径与每次都加锁的慢速路径在并发下的开销
+func BenchmarkGenerateContention(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		fast bool
+	}{{"cas", true}, {"mutex", false}} {
+		b.Run(bc.name, func(b *testing.B) {
+			g := newBenchGenerator(b, wideSequence...)
+			g.fast = bc.fast
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := g.Generate(); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+func BenchmarkGenerate(b *testing.B) {
+	g := newBenchGenerator(b, wideSequence...)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.Generate(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// 以 1、2、4、8 以及 GOMAXPROCS 个 goroutine 并发调用 Generate，RunParallel 的 goroutine 数等于 GOMAXPROCS，
+// 因此每个子测试临时将 GOMAXPROCS 设为对应的并发数
+func BenchmarkGenerateParallel(b *testing.B) {
+	// 0 表示使用当前的 GOMAXPROCS
+	for _, n := range []int{1, 2, 4, 8, 0} {
+		name := "goroutines-" + strconv.Itoa(n)
+		if n == 0 {
+			n, name = runtime.GOMAXPROCS(0), "gomaxprocs"
+		}
+		b.Run(name, func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(n))
+			g := newBenchGenerator(b, wideSequence...)
+			b.RunParallel(func(pb *testing.PB) {
+				for pb.Next() {
+					if _, err := g.Generate(); err != nil {
+						b.Error(err)
+						return
+					}
+				}
+			})
+		})
+	}
+}
+
+// 使用默认布局持续生成，每毫秒的 4096 个序列号都会被用完而进入等待下一毫秒的分支，
+// 衡量序列号耗尽时各退避策略下的吞吐，seq-waits/op 为平均每个 ID 触发的等待次数
+func BenchmarkGenerateExhausted(b *testing.B) {
+	for _, bc := range []struct {
+		name    string
+		backoff Backoff
+	}{{"spin", SpinBackoff{}}, {"gosched", GoschedBackoff{}}, {"sleep", SleepBackoff(100 * time.Microsecond)}} {
+		b.Run(bc.name, func(b *testing.B) {
+			g := newBenchGenerator(b, WithBackoff(bc.backoff))
+			for i := 0; i < b.N; i++ {
+				if _, err := g.Generate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(g.Stats().SequenceWaits)/float64(b.N), "seq-waits/op")
+		})
+	}
+}
+
+// 单 goroutine 下对照同一毫秒内有剩余序列号时的快速路径与加锁的慢速路径，衡量快速路径拆分带来的收益
+func BenchmarkGenerateHotPath(b *testing.B) {
+	for _, bc := range []struct {
+		name string
+		fast bool
+	}{{"fast", true}, {"locked", false}} {
+		b.Run(bc.name, func(b *testing.B) {
+			g := newBenchGenerator(b, wideSequence...)
+			g.fast = bc.fast
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := g.Generate(); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,1287 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/rand"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// 测试用时间源，时间只在调用 Set、Add 时变化
+type manualClock struct {
+	milli atomic.Int64
+}
+
+func newManualClock(milli int64) *manualClock {
+	c := &manualClock{}
+	c.milli.Store(milli)
+	return c
+}
+
+func (c *manualClock) NowMilli() int64 {
+	return c.milli.Load()
+}
+
+func (c *manualClock) Set(milli int64) {
+	c.milli.Store(milli)
+}
+
+func (c *manualClock) Add(delta int64) {
+	c.milli.Add(delta)
+}
+
+// 每次等待都将时间源推进一毫秒的退避策略，使等待时钟前进的分支无需真实休眠
+type tickBackoff struct {
+	clock *manualClock
+	waits atomic.Int64
+}
+
+func (b *tickBackoff) Wait(int64) {
+	b.waits.Add(1)
+	b.clock.Add(1)
+}
+
+func newTestGenerator(t *testing.T, clock Clock, opts ...Option) *IDGenerator {
+	t.Helper()
+	g, err := NewIDGenerator(1, 1, append([]Option{WithClock(clock)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return g
+}
+
+func TestClockBackTolerance(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	backoff := &tickBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithClockBackTolerance(5*time.Millisecond), WithBackoff(backoff))
+	first, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 回拨 3ms，在容忍范围内，等待时钟追上后继续生成
+	clock.Add(-3)
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatalf("rollback within tolerance: %v", err)
+	}
+	if id <= first {
+		t.Fatalf("got %d after %d, want increasing", id, first)
+	}
+	if backoff.waits.Load() != 3 {
+		t.Fatalf("got %d waits, want 3", backoff.waits.Load())
+	}
+
+	// 回拨 6ms，超出容忍范围
+	clock.Add(-6)
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("rollback beyond tolerance: got %v, want ErrClockBack", err)
+	}
+}
+
+func TestInjectedClock(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	backoff := &tickBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithBackoff(backoff))
+	for i := int64(0); i <= MaxSequenceID(); i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ts, _, _, seq := Decompose(id); ts != epoch+1000 || seq != i {
+			t.Fatalf("ID %d got timestamp %d sequence %d, want %d %d", i, ts, seq, epoch+1000, i)
+		}
+	}
+	if backoff.waits.Load() != 0 {
+		t.Fatalf("got %d waits before exhaustion, want 0", backoff.waits.Load())
+	}
+
+	// 序列号耗尽，等待时钟进入下一毫秒
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _, seq := Decompose(id); ts != epoch+1001 || seq != 0 {
+		t.Fatalf("after exhaustion got timestamp %d sequence %d, want %d 0", ts, seq, epoch+1001)
+	}
+
+	clock.Add(-1)
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want ErrClockBack", err)
+	}
+}
+
+func TestGenerateN(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock, WithBackoff(&tickBackoff{clock: clock}))
+	ids, err := g.GenerateN(int(MaxSequenceID()) + 1 + 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids[%d] = %d not greater than ids[%d] = %d", i, ids[i], i-1, ids[i-1])
+		}
+	}
+	if ts, _, _, seq := Decompose(ids[len(ids)-1]); ts != epoch+1001 || seq != 9 {
+		t.Fatalf("last ID got timestamp %d sequence %d, want %d 9", ts, seq, epoch+1001)
+	}
+}
+
+func TestGenerateNReturnError(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	backoff := &tickBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithSequenceBits(4), WithSequenceOverflowPolicy(ReturnError), WithBackoff(backoff))
+	ids, err := g.GenerateN(20)
+	if !errors.Is(err, ErrSequenceExhausted) {
+		t.Fatalf("got %v, want ErrSequenceExhausted", err)
+	}
+	if len(ids) != 16 || backoff.waits.Load() != 0 {
+		t.Fatalf("got %d IDs after %d waits, want the 16 IDs of the current millisecond without waiting", len(ids), backoff.waits.Load())
+	}
+	clock.Add(1)
+	if ids, err = g.GenerateN(4); err != nil || len(ids) != 4 {
+		t.Fatalf("after the next millisecond got %d IDs, %v", len(ids), err)
+	}
+}
+
+func TestGenerateContextCancel(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock)
+	if _, err := g.GenerateN(int(MaxSequenceID()) + 1); err != nil {
+		t.Fatal(err)
+	}
+	// 时钟停住，序列号耗尽后一直等待下一毫秒，直到 ctx 超时
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := g.GenerateContext(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want context.DeadlineExceeded", err)
+	}
+	// 取消不影响之后的生成
+	clock.Add(1)
+	if _, err := g.GenerateContext(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestGenerateConcurrent(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const goroutines, perGoroutine = 64, 2000
+	results := make([][]int64, goroutines)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			ids := make([]int64, 0, perGoroutine)
+			for j := 0; j < perGoroutine; j++ {
+				id, err := g.Generate()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				ids = append(ids, id)
+			}
+			results[i] = ids
+		}(i)
+	}
+	wg.Wait()
+	seen := make(map[int64]struct{}, goroutines*perGoroutine)
+	for _, ids := range results {
+		for j, id := range ids {
+			if _, ok := seen[id]; ok {
+				t.Fatalf("duplicate ID %d", id)
+			}
+			seen[id] = struct{}{}
+			// 同一 goroutine 内先后生成的 ID 严格递增
+			if j > 0 && id <= ids[j-1] {
+				t.Fatalf("got %d after %d in one goroutine", id, ids[j-1])
+			}
+		}
+	}
+	if len(seen) != goroutines*perGoroutine {
+		t.Fatalf("got %d IDs, want %d", len(seen), goroutines*perGoroutine)
+	}
+}
+
+func TestSequenceExhaustionHarness(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		opts []Option
+	}{
+		{"fast path", nil},
+		{"locked path", []Option{WithStrictChecks()}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			clock := newManualClock(epoch + 1000)
+			backoff := &tickBackoff{clock: clock}
+			g := newTestGenerator(t, clock, append(tt.opts, WithBackoff(backoff))...)
+			// 时钟停在同一毫秒，调用次数超过一毫秒的序列号容量，耗尽时由 tickBackoff 推进一毫秒
+			n := int(MaxSequenceID()) + 1 + 500
+			seen := make(map[int64]struct{}, n)
+			prev := int64(-1)
+			for i := 0; i < n; i++ {
+				id, err := g.Generate()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if _, ok := seen[id]; ok {
+					t.Fatalf("call %d: duplicate ID %d", i, id)
+				}
+				seen[id] = struct{}{}
+				if id <= prev {
+					t.Fatalf("call %d: got %d after %d, want strictly increasing", i, id, prev)
+				}
+				prev = id
+				ts, _, _, seq := Decompose(id)
+				wantTs, wantSeq := int64(epoch+1000), int64(i)
+				if i > int(MaxSequenceID()) {
+					wantTs, wantSeq = epoch+1001, int64(i)-MaxSequenceID()-1
+				}
+				if ts != wantTs || seq != wantSeq {
+					t.Fatalf("call %d: got timestamp %d sequence %d, want %d %d", i, ts, seq, wantTs, wantSeq)
+				}
+			}
+			if backoff.waits.Load() != 1 {
+				t.Fatalf("got %d waits, want 1", backoff.waits.Load())
+			}
+		})
+	}
+}
+
+func TestGenerateSpaced(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock, WithBackoff(&tickBackoff{clock: clock}))
+	// 先在当前毫秒生成一个普通 ID，GenerateSpaced 必须进入下一毫秒
+	first, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	prevTs, _, _, _ := Decompose(first)
+	for i := 0; i < 100; i++ {
+		id, err := g.GenerateSpaced()
+		if err != nil {
+			t.Fatal(err)
+		}
+		ts, _, _, seq := Decompose(id)
+		if ts <= prevTs || seq != 0 {
+			t.Fatalf("call %d: got timestamp %d sequence %d after timestamp %d", i, ts, seq, prevTs)
+		}
+		prevTs = ts
+	}
+}
+
+func TestTimestampOverflow(t *testing.T) {
+	for _, bits := range []int{41, 30} {
+		maxTimestamp := int64(1)<<bits - 1
+		clock := newManualClock(epoch + maxTimestamp)
+		g := newTestGenerator(t, clock, WithTimestampBits(bits))
+		// 时间戳字段的最后一毫秒仍可生成
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatalf("%d bits at last millisecond: %v", bits, err)
+		}
+		if ts, _, _, _ := g.Decompose(id); ts != epoch+maxTimestamp {
+			t.Fatalf("%d bits: got timestamp %d, want %d", bits, ts, epoch+maxTimestamp)
+		}
+		clock.Add(1)
+		if _, err := g.Generate(); !errors.Is(err, ErrTimestampOverflow) {
+			t.Fatalf("%d bits past last millisecond: got %v, want ErrTimestampOverflow", bits, err)
+		}
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock, WithIDCBits(3), WithMachineBits(3))
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	future, err := g.Compose(epoch+1001, 1, 1, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		id   int64
+		want bool
+	}{
+		{"generated", id, true},
+		{"zero", 0, true},
+		{"negative", -id, false},
+		{"flag bit", id | flagBit, false},
+		{"future timestamp", future, false},
+		{"bit above layout", id | 1<<59, false},
+		{"max int64", maxID, false},
+	}
+	for _, tt := range tests {
+		if got := g.IsValid(tt.id); got != tt.want {
+			t.Errorf("%s: IsValid(%d) = %v, want %v", tt.name, tt.id, got, tt.want)
+		}
+	}
+
+	signed := newTestGenerator(t, clock, WithSignedMode())
+	flagged, err := signed.GenerateWithFlag(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !signed.IsValid(flagged) {
+		t.Errorf("signed mode: IsValid(%d) = false, want true", flagged)
+	}
+}
+
+func TestGenerateString(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock)
+	s, err := g.GenerateString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strconv.FormatInt(Compose(epoch+1000, 1, 1, 0), 10); s != want {
+		t.Fatalf("got %q, want %q", s, want)
+	}
+	clock.Add(-1)
+	if s, err := g.GenerateString(); s != "" || !errors.Is(err, ErrClockBack) {
+		t.Fatalf("after rollback got %q, %v, want empty string and ErrClockBack", s, err)
+	}
+}
+
+func TestNewIDGeneratorContinuing(t *testing.T) {
+	clock := newManualClock(epoch + 1000)
+	prev, err := NewIDGenerator(1, MaxMachineID(), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	last, err := prev.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一毫秒内，机器号更小的新生成器如果从头开始，生成的 ID 会小于 last
+	g, err := NewIDGeneratorContinuing(prev, 1, 0, WithClock(clock), WithBackoff(&tickBackoff{clock: clock}),
+		WithMachineBits(6), WithSequenceBits(11))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first <= last {
+		t.Fatalf("first ID %d from new generator not greater than last ID %d from prev", first, last)
+	}
+
+	// 缩小时间戳的偏移量可能使新 ID 小于旧 ID，构造失败
+	if _, err := NewIDGeneratorContinuing(prev, 1, 0, WithClock(clock), WithSequenceBits(10)); !errors.Is(err, ErrInvaildLayout) {
+		t.Fatalf("got %v, want ErrInvaildLayout", err)
+	}
+}
+
+func TestSequenceOverflowPolicy(t *testing.T) {
+	perMilli := int(MaxSequenceID()) + 1
+
+	clock := newManualClock(epoch + 1000)
+	g := newTestGenerator(t, clock, WithSequenceOverflowPolicy(ReturnError))
+	for i := 0; i < perMilli; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := g.Generate(); !errors.Is(err, ErrSequenceExhausted) {
+		t.Fatalf("ReturnError: got %v, want ErrSequenceExhausted", err)
+	}
+	clock.Add(1)
+	if _, err := g.Generate(); err != nil {
+		t.Fatalf("ReturnError after next millisecond: %v", err)
+	}
+
+	clock = newManualClock(epoch + 1000)
+	backoff := &tickBackoff{clock: clock}
+	g = newTestGenerator(t, clock, WithSequenceOverflowPolicy(WaitNextMilli), WithBackoff(backoff))
+	for i := 0; i < perMilli; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatalf("WaitNextMilli: %v", err)
+	}
+	if ts, _, _, seq := Decompose(id); ts != epoch+1001 || seq != 0 || backoff.waits.Load() != 1 {
+		t.Fatalf("WaitNextMilli: got timestamp %d sequence %d after %d waits", ts, seq, backoff.waits.Load())
+	}
+}
+
+func FuzzGenerateMonotonic(f *testing.F) {
+	f.Add([]byte{0, 0, 1, 0, 2})
+	f.Add([]byte{0, 255, 0, 1, 254, 3})
+	f.Fuzz(func(t *testing.T, deltas []byte) {
+		clock := newManualClock(epoch + 1000)
+		g := newTestGenerator(t, clock, WithBackoff(&tickBackoff{clock: clock}))
+		prev, lastMilli := int64(-1), int64(-1)
+		for _, d := range deltas {
+			// 每个字节按 int8 解释为时钟的变化量，负数即时钟回拨
+			clock.Add(int64(int8(d)))
+			now := clock.NowMilli()
+			id, err := g.Generate()
+			switch {
+			case now < epoch:
+				if !errors.Is(err, ErrClockBeforeEpoch) {
+					t.Fatalf("clock %d before epoch: got %d, %v", now, id, err)
+				}
+				continue
+			case now < lastMilli:
+				if !errors.Is(err, ErrClockBack) {
+					t.Fatalf("clock %d before last %d: got %d, %v", now, lastMilli, id, err)
+				}
+				continue
+			case err != nil:
+				t.Fatalf("clock %d: %v", now, err)
+			}
+			if id <= prev {
+				t.Fatalf("got %d after %d", id, prev)
+			}
+			prev = id
+			lastMilli, _, _, _ = Decompose(id)
+		}
+	})
+}
+
+func TestReserveConcurrent(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	const k, goroutines = 10000, 8
+	stop := make(chan struct{})
+	others := make([][]int64, goroutines)
+	var wg sync.WaitGroup
+	for i := range others {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				id, err := g.Generate()
+				if err != nil {
+					t.Error(err)
+					return
+				}
+				others[i] = append(others[i], id)
+			}
+		}(i)
+	}
+	start, ids, err := g.Reserve(k)
+	close(stop)
+	wg.Wait()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ids) != k || start != ids[0] {
+		t.Fatalf("got %d IDs starting at %d, want %d starting at %d", len(ids), start, k, ids[0])
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] <= ids[i-1] {
+			t.Fatalf("ids[%d] = %d not greater than ids[%d] = %d", i, ids[i], i-1, ids[i-1])
+		}
+	}
+	// 预留区间内连续占用序列号，并发生成的 ID 都落在区间之外
+	end := ids[len(ids)-1]
+	for _, generated := range others {
+		for _, id := range generated {
+			if id >= start && id <= end {
+				t.Fatalf("concurrent ID %d interleaves with reserved range [%d, %d]", id, start, end)
+			}
+		}
+	}
+}
+
+func TestReservePartial(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithSequenceBits(6), WithRandomBits(2), WithSequenceOverflowPolicy(ReturnError))
+	_, ids, err := g.Reserve(20)
+	if !errors.Is(err, ErrSequenceExhausted) || len(ids) != 16 {
+		t.Fatalf("got %d IDs, %v, want the 16 IDs of the current millisecond and ErrSequenceExhausted", len(ids), err)
+	}
+	if last, ok := g.Last(); !ok || last != ids[len(ids)-1] {
+		t.Fatalf("Last got %d, %v, want the last returned ID %d", last, ok, ids[len(ids)-1])
+	}
+	if n := g.Stats().Generated; n != 16 {
+		t.Fatalf("Stats counted %d IDs, want 16", n)
+	}
+}
+
+func TestCloseConcurrent(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if _, err := g.Generate(); err != nil {
+					if !errors.Is(err, ErrClosed) {
+						t.Errorf("got %v, want ErrClosed", err)
+					}
+					return
+				}
+			}
+		}()
+	}
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := g.Close(); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if err := g.Close(); err != nil {
+		t.Fatalf("repeated Close: %v", err)
+	}
+	if _, err := g.Generate(); !errors.Is(err, ErrClosed) {
+		t.Fatalf("Generate after Close: got %v, want ErrClosed", err)
+	}
+}
+
+func TestGenerateAllocs(t *testing.T) {
+	if raceEnabled {
+		t.Skip("allocation counts are not meaningful with the race detector")
+	}
+	for _, tc := range []struct {
+		name string
+		opts []Option
+	}{
+		{"default", nil},
+		{"strict", []Option{WithStrictChecks()}},
+		{"random", []Option{WithRandomBits(4)}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			g := newTestGenerator(t, newManualClock(int64(epoch+1000)), append(wideSequence, tc.opts...)...)
+			allocs := testing.AllocsPerRun(1000, func() {
+				if _, err := g.Generate(); err != nil {
+					t.Fatal(err)
+				}
+			})
+			if allocs != 0 {
+				t.Fatalf("got %v allocs per Generate, want 0", allocs)
+			}
+		})
+	}
+}
+
+func TestGenerateAt(t *testing.T) {
+	clock := newManualClock(int64(epoch + 100000))
+	g := newTestGenerator(t, clock)
+	at := time.UnixMilli(epoch + 5000)
+	for i := int64(0); i < 5; i++ {
+		id, err := g.GenerateAt(at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		milli, idcID, machineID, seq := g.Decompose(id)
+		if milli != at.UnixMilli() || idcID != 1 || machineID != 1 || seq != i {
+			t.Fatalf("got (%d, %d, %d, %d), want (%d, 1, 1, %d)", milli, idcID, machineID, seq, at.UnixMilli(), i)
+		}
+	}
+	if g.LastMilli() != -1 {
+		t.Fatalf("GenerateAt changed LastMilli to %d", g.LastMilli())
+	}
+
+	if _, err := g.GenerateAt(at.Add(-time.Millisecond)); !errors.Is(err, ErrAtTimeBack) {
+		t.Fatalf("got %v, want ErrAtTimeBack", err)
+	}
+	if _, err := g.GenerateAt(time.UnixMilli(epoch - 1)); !errors.Is(err, ErrInvaildTime) {
+		t.Fatalf("got %v, want ErrInvaildTime", err)
+	}
+	id, err := g.GenerateAt(at.Add(time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, _, seq := g.Decompose(id); seq != 0 {
+		t.Fatalf("got sequence %d in a new millisecond, want 0", seq)
+	}
+}
+
+func TestReservedZero(t *testing.T) {
+	for _, tc := range []struct {
+		idcID, machineID int64
+		err              error
+	}{
+		{0, 1, ErrInvaildIDCID},
+		{1, 0, ErrInvaildMachineID},
+		{0, 0, ErrInvaildIDCID},
+		{1, 1, nil},
+		{31, 31, nil},
+	} {
+		if _, err := NewIDGenerator(tc.idcID, tc.machineID, WithReservedZero()); !errors.Is(err, tc.err) {
+			t.Fatalf("NewIDGenerator(%d, %d): got %v, want %v", tc.idcID, tc.machineID, err, tc.err)
+		}
+	}
+	// 不开启时 0 仍然合法
+	if _, err := NewIDGenerator(0, 0); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClone(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithSequenceBits(10))
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	c, err := g.Clone(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Epoch() != g.Epoch() || c.MaxSequenceID() != g.MaxSequenceID() || c.IDCID != g.IDCID || c.machineID != 7 {
+		t.Fatalf("clone did not copy the configuration")
+	}
+	if &c.mutex == &g.mutex || c.LastMilli() != -1 {
+		t.Fatalf("clone shares runtime state")
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := c.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if s := g.Snapshot(); s.SequenceID != 0 {
+		t.Fatalf("generating from the clone changed the original sequence to %d", s.SequenceID)
+	}
+	if _, err := g.Clone(g.MaxMachineID() + 1); !errors.Is(err, ErrInvaildMachineID) {
+		t.Fatalf("got %v, want ErrInvaildMachineID", err)
+	}
+}
+
+func TestDriftCallback(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	var g *IDGenerator
+	var deltas []time.Duration
+	// WithDriftCallback 在 WithClock 之前，生成 ID 仍使用注入的时间源
+	g, err := NewIDGenerator(1, 1, WithDriftCallback(func(delta time.Duration) {
+		// 回调在锁外调用，可以再次使用生成器
+		g.Snapshot()
+		deltas = append(deltas, delta)
+	}), WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 伪造已观察到 5ms 的漂移，下一次取时间时检测到约 -5ms 的变化
+	g.drift.lastDrift.Store(int64(5 * time.Millisecond))
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _, _ := Decompose(id); ts != int64(epoch+1000) {
+		t.Fatalf("got timestamp %d, want the injected clock %d", ts, int64(epoch+1000))
+	}
+	if len(deltas) != 1 || deltas[0] > -4*time.Millisecond {
+		t.Fatalf("got drift reports %v, want one of about -5ms", deltas)
+	}
+}
+
+func TestCloneDriftClock(t *testing.T) {
+	var calls atomic.Int64
+	g, err := NewIDGenerator(1, 1, WithDriftCallback(func(time.Duration) { calls.Add(1) }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := g.Clone(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.drift == g.drift || c.clock != Clock(c.drift) {
+		t.Fatalf("clone shares the drift clock")
+	}
+	// 伪造原 IDGenerator 已观察到 5ms 的漂移，克隆出的实例不应感知到；两者首次生成都会经过加锁路径并在解锁后报告漂移
+	g.drift.lastDrift.Store(int64(5 * time.Millisecond))
+	if _, err := c.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 0 {
+		t.Fatalf("the clone reported %d drifts seen by the original", n)
+	}
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if n := calls.Load(); n != 1 {
+		t.Fatalf("the original reported %d drifts, want 1", n)
+	}
+}
+
+func TestClockBeforeEpoch(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	clock.Set(int64(epoch - 1))
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBeforeEpoch) {
+		t.Fatalf("got %v, want ErrClockBeforeEpoch", err)
+	}
+	clock.Set(int64(epoch + 1000))
+	if _, err := g.Generate(); err != nil {
+		t.Fatalf("generate after the clock recovered: %v", err)
+	}
+}
+
+func TestCapacity(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := g.CapacityPerSecond(); got != 4096*1000 {
+		t.Fatalf("CapacityPerSecond: got %d, want %d", got, 4096*1000)
+	}
+	for _, tc := range []struct {
+		d    time.Duration
+		want int64
+	}{
+		{time.Millisecond, 4096},
+		{time.Minute, 4096 * 60000},
+		{1500 * time.Microsecond, 4096},
+		{0, 0},
+	} {
+		if got := g.CapacityOver(tc.d); got != tc.want {
+			t.Fatalf("CapacityOver(%v): got %d, want %d", tc.d, got, tc.want)
+		}
+	}
+}
+
+func TestMillisForBurst(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	for _, tc := range []struct {
+		n    int
+		want int64
+	}{{0, 0}, {1, 1}, {4096, 1}, {4097, 2}, {3 * 4096, 3}, {3*4096 + 1, 4}} {
+		if got := g.MillisForBurst(tc.n); got != tc.want {
+			t.Fatalf("fresh millisecond, n=%d: got %d, want %d", tc.n, got, tc.want)
+		}
+	}
+
+	// 当前毫秒已用掉 4000 个序列号，只剩 96 个
+	if _, err := g.GenerateN(4000); err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		n    int
+		want int64
+	}{{96, 1}, {97, 2}, {96 + 4096, 2}, {96 + 4096 + 1, 3}} {
+		if got := g.MillisForBurst(tc.n); got != tc.want {
+			t.Fatalf("partly used millisecond, n=%d: got %d, want %d", tc.n, got, tc.want)
+		}
+	}
+
+	// 当前毫秒已用完，突发从下一毫秒开始
+	if _, err := g.GenerateN(96); err != nil {
+		t.Fatal(err)
+	}
+	if got := g.MillisForBurst(4096); got != 1 {
+		t.Fatalf("exhausted millisecond, n=4096: got %d, want 1", got)
+	}
+}
+
+func TestGenerateWaitRollback(t *testing.T) {
+	start := int64(epoch + 1000)
+	clock := newManualClock(start)
+	g := newTestGenerator(t, clock)
+	first, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 时钟回拨后在等待时长内恢复
+	clock.Set(start - 50)
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		clock.Set(start + 1)
+	}()
+	id, err := g.GenerateWaitRollback(time.Second)
+	if err != nil {
+		t.Fatalf("clock recovered within the wait: %v", err)
+	}
+	if id <= first {
+		t.Fatalf("got %d after %d, want increasing", id, first)
+	}
+
+	// 时钟回拨后超过等待时长仍未恢复
+	clock.Set(start - 50)
+	begin := time.Now()
+	_, err = g.GenerateWaitRollback(20 * time.Millisecond)
+	var clockBack *ClockBackError
+	if !errors.As(err, &clockBack) || !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want *ClockBackError", err)
+	}
+	if clockBack.LastMilli != start+1 || clockBack.NowMilli != start-50 {
+		t.Fatalf("got %+v, want last %d now %d", clockBack, start+1, start-50)
+	}
+	if elapsed := time.Since(begin); elapsed < 20*time.Millisecond {
+		t.Fatalf("returned after %v, want to wait at least 20ms", elapsed)
+	}
+}
+
+func TestGenerateWithSequence(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	for _, seq := range []int64{0, 1, 2048, g.MaxSequenceID()} {
+		id, err := g.GenerateWithSequence(seq)
+		if err != nil {
+			t.Fatalf("sequence %d: %v", seq, err)
+		}
+		if ts, _, _, got := g.Decompose(id); ts != clock.NowMilli() || got != seq {
+			t.Fatalf("got (%d, %d), want (%d, %d)", ts, got, clock.NowMilli(), seq)
+		}
+	}
+	for _, seq := range []int64{-1, g.MaxSequenceID() + 1} {
+		if _, err := g.GenerateWithSequence(seq); !errors.Is(err, ErrInvaildSequenceID) {
+			t.Fatalf("sequence %d: got %v, want ErrInvaildSequenceID", seq, err)
+		}
+	}
+	// 不影响内部计数
+	if g.LastMilli() != -1 {
+		t.Fatalf("GenerateWithSequence changed LastMilli to %d", g.LastMilli())
+	}
+}
+
+func TestRemainingThisMilli(t *testing.T) {
+	for _, tc := range []struct {
+		name string
+		opts []Option
+		want int64 // 新毫秒保证可生成的 ID 数
+	}{
+		{"default", nil, 4096},
+		{"randomized start", []Option{WithRandomizedSequenceStart(rand.New(rand.NewSource(1)))}, 2049},
+		{"checksum bit", []Option{WithChecksumBit()}, 2048},
+		{"both", []Option{WithRandomizedSequenceStart(rand.New(rand.NewSource(1))), WithChecksumBit()}, 1025},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := newManualClock(int64(epoch + 1000))
+			g := newTestGenerator(t, clock, tc.opts...)
+			if got := g.RemainingThisMilli(); got != tc.want {
+				t.Fatalf("fresh millisecond: got %d, want %d", got, tc.want)
+			}
+			// 按返回值生成不会触发等待，之后的剩余数量与实际状态一致
+			ids, err := g.GenerateN(int(tc.want))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, _, _, seq := g.Decompose(ids[len(ids)-1]); g.LastMilli() != int64(epoch+1000) || g.RemainingThisMilli() != g.MaxSequenceID()-seq {
+				t.Fatalf("got %d remaining after sequence %d", g.RemainingThisMilli(), seq)
+			}
+		})
+	}
+}
+
+func TestPeek(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	backoff := &tickBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithSequenceBits(2), WithBackoff(backoff))
+	// 包含首次生成、同一毫秒递增、序列号用完后进入下一毫秒以及时钟前进的情况
+	for i := 0; i < 10; i++ {
+		if i == 7 {
+			clock.Add(5)
+		}
+		peeked, err := g.Peek()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if again, _ := g.Peek(); again != peeked {
+			t.Fatalf("second Peek got %d, want %d", again, peeked)
+		}
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id != peeked {
+			t.Fatalf("ID %d: Peek got %d, Generate got %d", i, peeked, id)
+		}
+	}
+}
+
+func TestStrictChecks(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock, WithStrictChecks())
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	// 构造后直接修改导出字段使 IDC 号超出 5 位
+	g.IDCID = 40
+	_, err := g.Generate()
+	if !errors.Is(err, ErrInvaildIDCID) {
+		t.Fatalf("got %v, want ErrInvaildIDCID", err)
+	}
+	if !strings.Contains(err.Error(), "40") {
+		t.Fatalf("error %q does not describe the field value", err)
+	}
+	if _, ids, err := g.Reserve(3); !errors.Is(err, ErrInvaildIDCID) || len(ids) != 0 {
+		t.Fatalf("Reserve got %d IDs, %v, want ErrInvaildIDCID", len(ids), err)
+	}
+}
+
+func TestEpochAndLayout(t *testing.T) {
+	if DefaultEpoch() != 1669046400000 {
+		t.Fatalf("DefaultEpoch: got %d, want 1669046400000", DefaultEpoch())
+	}
+	if ts, idc, machine, seq := Layout(); ts != 41 || idc != 5 || machine != 5 || seq != 12 {
+		t.Fatalf("Layout: got (%d, %d, %d, %d), want (41, 5, 5, 12)", ts, idc, machine, seq)
+	}
+
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Epoch() != DefaultEpoch() {
+		t.Fatalf("default Epoch: got %d, want %d", g.Epoch(), DefaultEpoch())
+	}
+	custom := DefaultEpoch() + 24*3600*1000
+	g, err = NewIDGeneratorWithEpoch(1, 1, custom, WithTimestampBits(40), WithSequenceBits(13))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Epoch() != custom {
+		t.Fatalf("custom Epoch: got %d, want %d", g.Epoch(), custom)
+	}
+	if ts, idc, machine, seq := g.Layout(); ts != 40 || idc != 5 || machine != 5 || seq != 13 {
+		t.Fatalf("custom Layout: got (%d, %d, %d, %d), want (40, 5, 5, 13)", ts, idc, machine, seq)
+	}
+}
+
+func TestLastMilliConcurrent(t *testing.T) {
+	start := int64(epoch + 1000)
+	clock := newManualClock(start)
+	g := newTestGenerator(t, clock, WithBackoff(&tickBackoff{clock: clock}))
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20000; j++ {
+				if j%100 == 0 {
+					clock.Add(1)
+				}
+				if _, err := g.Generate(); err != nil {
+					t.Error(err)
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	// 读到的值只能是 -1 或已经出现过的毫秒时间，且不会倒退
+	last := int64(-1)
+	for {
+		select {
+		case <-done:
+			if got := g.LastMilli(); got < last || got > clock.NowMilli() {
+				t.Fatalf("final LastMilli %d, want in [%d, %d]", got, last, clock.NowMilli())
+			}
+			return
+		default:
+		}
+		milli := g.LastMilli()
+		if milli != -1 && (milli < start || milli > clock.NowMilli()) || milli < last {
+			t.Fatalf("got LastMilli %d after %d, clock at %d", milli, last, clock.NowMilli())
+		}
+		last = milli
+	}
+}
+
+func TestNewIDGeneratorValidated(t *testing.T) {
+	future := time.Now().Add(time.Hour).UnixMilli()
+	_, err := NewIDGeneratorValidated(40, -1, future, WithTimestampBits(60))
+	var validation *ValidationError
+	if !errors.As(err, &validation) {
+		t.Fatalf("got %v, want *ValidationError", err)
+	}
+	want := []error{ErrInvaildLayout, ErrInvaildIDCID, ErrInvaildMachineID, ErrInvaildEpoch}
+	if len(validation.Errs) != len(want) {
+		t.Fatalf("got %d errors %v, want %d", len(validation.Errs), validation.Errs, len(want))
+	}
+	for i, target := range want {
+		if !errors.Is(validation.Errs[i], target) || !errors.Is(err, target) {
+			t.Fatalf("error %d: got %v, want %v", i, validation.Errs[i], target)
+		}
+	}
+
+	// 单个问题同样以 *ValidationError 返回
+	_, err = NewIDGeneratorValidated(1, 32, epoch)
+	if !errors.As(err, &validation) || len(validation.Errs) != 1 || !errors.Is(err, ErrInvaildMachineID) {
+		t.Fatalf("got %v, want only ErrInvaildMachineID", err)
+	}
+	if _, err := NewIDGeneratorValidated(1, 1, epoch); err != nil {
+		t.Fatal(err)
+	}
+}
+
+type recoverLogger struct {
+	clock     *manualClock
+	recovered int64 // 检测到回拨时将时钟恢复到的时间，0 表示不恢复
+	events    atomic.Int64
+}
+
+func (l *recoverLogger) Log(event string, _ map[string]any) {
+	if event != EventClockBack {
+		return
+	}
+	l.events.Add(1)
+	if l.recovered != 0 {
+		l.clock.Set(l.recovered)
+	}
+}
+
+func TestGenerateRetry(t *testing.T) {
+	start := int64(epoch + 1000)
+	for _, tc := range []struct {
+		name      string
+		recovered int64
+		events    int64
+		err       error
+	}{
+		{"recovers after the first failure", start + 1, 1, nil},
+		{"never recovers", 0, 4, ErrClockBack},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			clock := newManualClock(start)
+			// 检测到回拨时按 recovered 恢复时钟，恢复后重试即可成功
+			logger := &recoverLogger{clock: clock, recovered: tc.recovered}
+			g := newTestGenerator(t, clock, WithLogger(logger))
+			first, err := g.Generate()
+			if err != nil {
+				t.Fatal(err)
+			}
+			clock.Add(-10)
+			id, err := g.GenerateRetry(3, time.Millisecond)
+			if !errors.Is(err, tc.err) {
+				t.Fatalf("got %v, want %v", err, tc.err)
+			}
+			if err == nil && id <= first {
+				t.Fatalf("got %d after %d, want increasing", id, first)
+			}
+			if logger.events.Load() != tc.events {
+				t.Fatalf("got %d attempts failing on clock back, want %d", logger.events.Load(), tc.events)
+			}
+		})
+	}
+
+	// 其他错误不重试
+	g := newTestGenerator(t, newManualClock(start))
+	g.Close()
+	if _, err := g.GenerateRetry(3, time.Hour); !errors.Is(err, ErrClosed) {
+		t.Fatalf("got %v, want ErrClosed", err)
+	}
+}
+
+func TestTimeUntilExhaustion(t *testing.T) {
+	g, err := NewIDGenerator(1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 默认 41 位时间戳约可使用 69 年
+	if d := g.TimeUntilExhaustion(); d <= 0 || d > 70*365*24*time.Hour {
+		t.Fatalf("default layout: got %v", d)
+	}
+
+	clock := newManualClock(int64(epoch + 1000))
+	prev := time.Duration(math.MaxInt64)
+	for _, bits := range []int{41, 40, 35, 20, 11} {
+		g := newTestGenerator(t, clock, WithTimestampBits(bits))
+		want := time.Duration(int64(1)<<bits-1000) * time.Millisecond
+		d := g.TimeUntilExhaustion()
+		if d != want {
+			t.Fatalf("%d bits: got %v, want %v", bits, d, want)
+		}
+		if d >= prev {
+			t.Fatalf("%d bits: got %v, want less than %v", bits, d, prev)
+		}
+		prev = d
+	}
+
+	// 时钟前进时随之减少，用尽后为 0
+	g = newTestGenerator(t, clock, WithTimestampBits(11))
+	clock.Add(1000)
+	if d := g.TimeUntilExhaustion(); d != 48*time.Millisecond {
+		t.Fatalf("got %v, want 48ms", d)
+	}
+	clock.Add(48)
+	if d := g.TimeUntilExhaustion(); d != 0 {
+		t.Fatalf("exhausted: got %v, want 0", d)
+	}
+}
+
+func TestPaddedString(t *testing.T) {
+	for _, tc := range []struct {
+		opts  []Option
+		width int
+	}{
+		{nil, 19},
+		{[]Option{WithTimestampBits(30)}, 16},
+	} {
+		clock := newManualClock(int64(epoch + 1))
+		g := newTestGenerator(t, clock, append(tc.opts, WithPaddedString())...)
+		small, err := g.GenerateString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if id, _ := g.Last(); strconv.FormatInt(id, 10) != strings.TrimLeft(small, "0") {
+			t.Fatalf("padded %s does not match ID %d", small, id)
+		}
+		ts, _, _, _ := g.Layout()
+		clock.Set(epoch + int64(1)<<ts - 1)
+		large, err := g.GenerateString()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(small) != tc.width || len(large) != tc.width {
+			t.Fatalf("got lengths %d and %d, want %d", len(small), len(large), tc.width)
+		}
+		if small >= large || small[0] != '0' {
+			t.Fatalf("got %s and %s, want zero-padded and lexically ordered", small, large)
+		}
+	}
+}
+
+func TestAcceptClockBack(t *testing.T) {
+	start := int64(epoch + 1000)
+	clock := newManualClock(start)
+	backoff := &tickBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithBackoff(backoff))
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	clock.Add(-100)
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want ErrClockBack", err)
+	}
+
+	g.AcceptClockBack()
+	prev := int64(-1)
+	for i := 0; i < 5; i++ {
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatalf("after AcceptClockBack: %v", err)
+		}
+		if id <= prev {
+			t.Fatalf("got %d after %d, want increasing", id, prev)
+		}
+		prev = id
+		// 下一个 ID 从回拨后当前时间的下一毫秒开始
+		if ts, _, _, seq := g.Decompose(id); ts != start-99 || seq != int64(i) {
+			t.Fatalf("got (%d, %d), want (%d, %d)", ts, seq, start-99, i)
+		}
+	}
+
+	// 时钟早于 epoch 时恢复为初始状态，时钟回到 epoch 之后即可继续生成
+	clock.Set(epoch - 10)
+	g.AcceptClockBack()
+	if g.LastMilli() != -1 {
+		t.Fatalf("got LastMilli %d, want -1", g.LastMilli())
+	}
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBeforeEpoch) {
+		t.Fatalf("got %v, want ErrClockBeforeEpoch", err)
+	}
+	clock.Set(epoch + 10)
+	if _, err := g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetFloor(t *testing.T) {
+	start := int64(epoch + 1000)
+	clock := newManualClock(start)
+	backoff := &tickBackoff{clock: clock}
+	g := newTestGenerator(t, clock, WithBackoff(backoff), WithClockBackTolerance(10*time.Millisecond))
+
+	// 下限与当前时间相同：该毫秒视为已用完，等待到下一毫秒
+	if err := g.SetFloor(start); err != nil {
+		t.Fatal(err)
+	}
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _, seq := g.Decompose(id); ts != start+1 || seq != 0 || backoff.waits.Load() != 1 {
+		t.Fatalf("got (%d, %d) after %d waits, want (%d, 0) after 1", ts, seq, backoff.waits.Load(), start+1)
+	}
+
+	// 下限领先时钟 5ms，在容忍范围内，等待时钟越过下限
+	floor := clock.NowMilli() + 5
+	if err := g.SetFloor(floor); err != nil {
+		t.Fatal(err)
+	}
+	if id, err = g.Generate(); err != nil {
+		t.Fatal(err)
+	}
+	if ts, _, _, _ := g.Decompose(id); ts != floor+1 {
+		t.Fatalf("got timestamp %d, want %d", ts, floor+1)
+	}
+
+	// 超出容忍范围时与时钟回拨相同
+	if err := g.SetFloor(clock.NowMilli() + 50); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Generate(); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("got %v, want ErrClockBack", err)
+	}
+
+	if err := g.SetFloor(epoch - 1); !errors.Is(err, ErrInvaildTime) {
+		t.Fatalf("got %v, want ErrInvaildTime", err)
+	}
+	if err := g.SetFloor(epoch + g.layout.maxTimestamp + 1); !errors.Is(err, ErrTimestampOverflow) {
+		t.Fatalf("got %v, want ErrTimestampOverflow", err)
+	}
+	if err := g.SetFloor(epoch + g.layout.maxTimestamp); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestMonotonicGeneratorClockJumps(t *testing.T) {
+	clock := newManualClock(int64(epoch + 100000))
+	g, err := NewMonotonicGenerator(1, 1, WithClock(clock))
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[int64]bool{}
+	var last int64 = -1
+	for i := 0; i < 20000; i++ {
+		// 时钟反复前进又大幅回拨
+		switch i % 1000 {
+		case 0:
+			clock.Add(-500)
+		case 500:
+			clock.Add(300)
+		}
+		id, err := g.Generate()
+		if err != nil {
+			t.Fatalf("ID %d: %v", i, err)
+		}
+		if id <= last || seen[id] {
+			t.Fatalf("ID %d: got %d after %d, want increasing and unique", i, id, last)
+		}
+		seen[id] = true
+		last = id
+	}
+	if g.Stats().ClockBackEvents != 0 {
+		t.Fatalf("got %d clock back events, want rollbacks absorbed by the logical clock", g.Stats().ClockBackEvents)
+	}
+}
@@ -0,0 +1,81 @@
+package snowflake
+
+import (
+	"errors"
+	"fmt"
+	"sync/atomic"
+)
+
+var ErrNoProgress = errors.New("IDGenerator: health check failed, generated ID did not increase")
+
+// Stats IDGenerator 的运行计数
+type Stats struct {
+	Generated       int64 // 累计生成的 ID 数
+	SequenceWaits   int64 // 因同一毫秒内序列号耗尽而等待下一毫秒的次数，频繁出现说明单机容量接近上限
+	ClockBackEvents int64 // 检测到时钟回拨的次数，包括容忍范围内等待恢复的回拨
+}
+
+// 运行计数，快速路径不持锁，因此使用原子变量
+type counters struct {
+	generated       atomic.Int64
+	sequenceWaits   atomic.Int64
+	clockBackEvents atomic.Int64
+}
+
+// Stats 返回当前的运行计数
+func (g *IDGenerator) Stats() Stats {
+	return Stats{
+		Generated:       g.counters.generated.Load(),
+		SequenceWaits:   g.counters.sequenceWaits.Load(),
+		ClockBackEvents: g.counters.clockBackEvents.Load(),
+	}
+}
+
+// GeneratorState IDGenerator 内部状态的只读副本，用于排查问题时输出
+type GeneratorState struct {
+	LastMilli  int64 // 上一次生成 ID 的毫秒时间，尚未生成过 ID 时为 -1
+	SequenceID int64 // 本毫秒内的序列号
+	MachineID  int64 // 机器号
+	IDCID      int64 // IDC 号
+	Epoch      int64 // 开始使用时间，毫秒时间戳
+}
+
+func (s GeneratorState) String() string {
+	return fmt.Sprintf("IDGenerator{idc: %d, machine: %d, epoch: %d, lastMilli: %d, sequence: %d}",
+		s.IDCID, s.MachineID, s.Epoch, s.LastMilli, s.SequenceID)
+}
+
+// Snapshot 在持锁状态下复制一份生成器的内部状态，修改返回值不会影响生成器
+func (g *IDGenerator) Snapshot() GeneratorState {
+	g.mutex.Lock()
+	defer g.unlock()
+	lastMilli, sequenceID := g.unpack(g.state.Load())
+	return GeneratorState{
+		LastMilli:  lastMilli,
+		SequenceID: sequenceID,
+		MachineID:  g.machineID,
+		IDCID:      g.IDCID,
+		Epoch:      g.epoch,
+	}
+}
+
+// HealthCheck 连续生成两个 ID 并确认后一个严格大于前一个，用于存活探针等周期性检查
+// 生成出错时返回对应的错误，未递增时返回 ErrNoProgress，通常意味着时钟异常；
+// 比较按本 IDGenerator 的字段布局反解后的时间与序列号进行，因此同样适用于 WithFieldOrder 的自定义顺序
+// 每次调用会消耗两个 ID
+func (g *IDGenerator) HealthCheck() error {
+	first, err := g.Generate()
+	if err != nil {
+		return err
+	}
+	second, err := g.Generate()
+	if err != nil {
+		return err
+	}
+	firstMilli, _, _, firstSequenceID := g.Decompose(first)
+	secondMilli, _, _, secondSequenceID := g.Decompose(second)
+	if secondMilli < firstMilli || secondMilli == firstMilli && secondSequenceID <= firstSequenceID {
+		return ErrNoProgress
+	}
+	return nil
+}
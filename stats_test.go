@@ -0,0 +1,59 @@
+package snowflake
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestSnapshotIsolation(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	for i := 0; i < 3; i++ {
+		if _, err := g.Generate(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := g.Snapshot()
+	want := GeneratorState{LastMilli: int64(epoch + 1000), SequenceID: 2, MachineID: 1, IDCID: 1, Epoch: epoch}
+	if s != want {
+		t.Fatalf("got %v, want %v", s, want)
+	}
+
+	s.LastMilli, s.SequenceID, s.MachineID, s.IDCID, s.Epoch = 0, 0, 9, 9, 0
+	if got := g.Snapshot(); got != want {
+		t.Fatalf("mutating the snapshot changed the generator: got %v, want %v", got, want)
+	}
+	id, err := g.Generate()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, idcID, machineID, seq := g.Decompose(id); idcID != 1 || machineID != 1 || seq != 3 {
+		t.Fatalf("got idc %d machine %d sequence %d, want 1 1 3", idcID, machineID, seq)
+	}
+}
+
+// 每次读取都比上一次早一毫秒的时间源
+type backwardClock struct {
+	milli atomic.Int64
+}
+
+func (c *backwardClock) NowMilli() int64 {
+	return c.milli.Add(-1)
+}
+
+func TestHealthCheck(t *testing.T) {
+	g := newTestGenerator(t, newManualClock(int64(epoch+1000)))
+	for i := 0; i < 3; i++ {
+		if err := g.HealthCheck(); err != nil {
+			t.Fatalf("normal clock: %v", err)
+		}
+	}
+
+	clock := &backwardClock{}
+	clock.milli.Store(epoch + 1000)
+	g = newTestGenerator(t, clock)
+	if err := g.HealthCheck(); !errors.Is(err, ErrClockBack) {
+		t.Fatalf("backward clock: got %v, want ErrClockBack", err)
+	}
+}
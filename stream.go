@@ -0,0 +1,42 @@
+package snowflake
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// Stream 启动一个 goroutine 持续生成 ID 并写入容量为 buffer 的 channel，直到 ctx 被取消
+// 生成出错(例如 ErrClockBack)时停止生成并关闭 ID channel，错误写入配对的 error channel 后该 channel 也被关闭
+// ctx 被取消属于正常结束，不会写入错误；消费方可以直接 range ID channel，结束后再检查 error channel
+// 生成过程中发生 panic(例如自定义 Clock 的实现 panic)时同样关闭 channel，panic 以 *PanicError 写入 error channel，
+// 不会使进程崩溃；通过 WithoutStreamRecovery 关闭恢复后 panic 会照常传播，便于调试
+func (g *IDGenerator) Stream(ctx context.Context, buffer int) (<-chan int64, <-chan error) {
+	ids := make(chan int64, buffer)
+	errs := make(chan error, 1)
+	go func() {
+		defer close(errs)
+		defer close(ids)
+		if !g.streamNoRecover {
+			defer func() {
+				if r := recover(); r != nil {
+					errs <- &PanicError{Value: r, Stack: debug.Stack()}
+				}
+			}()
+		}
+		for {
+			id, err := g.GenerateContext(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					errs <- err
+				}
+				return
+			}
+			select {
+			case ids <- id:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return ids, errs
+}
@@ -0,0 +1,64 @@
+package snowflake
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+// 读取 limit 次之后 panic 的时间源
+type panicClock struct {
+	reads atomic.Int64
+	limit int64
+}
+
+func (c *panicClock) NowMilli() int64 {
+	if c.reads.Add(1) > c.limit {
+		panic("clock broken")
+	}
+	return epoch + 1000 + c.reads.Load()
+}
+
+func TestStreamPanic(t *testing.T) {
+	g := newTestGenerator(t, &panicClock{limit: 10})
+	ids, errs := g.Stream(context.Background(), 0)
+	var n int
+	for range ids {
+		n++
+	}
+	if n == 0 || n >= 10 {
+		t.Fatalf("got %d IDs before the panic, want between 1 and 9", n)
+	}
+	err := <-errs
+	var panicErr *PanicError
+	if !errors.As(err, &panicErr) {
+		t.Fatalf("got %v, want *PanicError", err)
+	}
+	if panicErr.Value != "clock broken" || len(panicErr.Stack) == 0 {
+		t.Fatalf("got value %v with %d bytes of stack", panicErr.Value, len(panicErr.Stack))
+	}
+	if _, ok := <-errs; ok {
+		t.Fatal("error channel not closed")
+	}
+}
+
+func TestStreamCancel(t *testing.T) {
+	g := newTestGenerator(t, newManualClock(int64(epoch+1000)))
+	ctx, cancel := context.WithCancel(context.Background())
+	ids, errs := g.Stream(ctx, 4)
+	var last int64 = -1
+	for i := 0; i < 100; i++ {
+		id := <-ids
+		if id <= last {
+			t.Fatalf("got %d after %d, want increasing", id, last)
+		}
+		last = id
+	}
+	cancel()
+	for range ids {
+	}
+	if err, ok := <-errs; ok {
+		t.Fatalf("got %v after cancel, want the error channel closed without an error", err)
+	}
+}
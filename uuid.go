@@ -0,0 +1,46 @@
+package snowflake
+
+import "crypto/rand"
+
+// GenerateUUID 生成一个 ID 并嵌入版本 8(自定义)UUID，便于与只接受 UUID 的系统对接
+// 字节布局(大端序，v 为去除符号位后的 63 位 ID)：
+//
+//	[0,6)  v 的第 62~15 位
+//	[6]    高 4 位为版本号 8，低 4 位为 v 的第 14~11 位
+//	[7]    v 的第 10~3 位
+//	[8]    高 2 位为变体 0b10，接着 3 位为 v 的第 2~0 位，低 3 位随机
+//	[9,16) 随机
+//
+// ID 位于 UUID 的高位，因此 UUID 按字节比较的顺序与 ID 一致；随机部分来自 crypto/rand
+func (g *IDGenerator) GenerateUUID() (uuid [16]byte, err error) {
+	id, err := g.Generate()
+	if err != nil {
+		return uuid, err
+	}
+	if _, err = rand.Read(uuid[8:]); err != nil {
+		return [16]byte{}, err
+	}
+	v := uint64(id) & maxID
+	uuid[0], uuid[1], uuid[2] = byte(v>>55), byte(v>>47), byte(v>>39)
+	uuid[3], uuid[4], uuid[5] = byte(v>>31), byte(v>>23), byte(v>>15)
+	uuid[6] = 0x80 | byte(v>>11)&0x0f
+	uuid[7] = byte(v >> 3)
+	uuid[8] = 0x80 | byte(v&0x07)<<3 | uuid[8]&0x07
+	return uuid, nil
+}
+
+// IDFromUUID 从 GenerateUUID 生成的 UUID 中取出嵌入的 ID
+func IDFromUUID(uuid [16]byte) int64 {
+	var v uint64
+	for _, b := range uuid[:6] {
+		v = v<<8 | uint64(b)
+	}
+	v = v<<15 | uint64(uuid[6]&0x0f)<<11 | uint64(uuid[7])<<3 | uint64(uuid[8]>>3&0x07)
+	return int64(v)
+}
+
+// DecomposeUUID 按默认 epoch 和 bit 布局反解 GenerateUUID 生成的 UUID 中嵌入的 ID，
+// 自定义布局的生成器应使用 g.Decompose(IDFromUUID(uuid))
+func DecomposeUUID(uuid [16]byte) (timestampMilli, idcID, machineID, sequenceID int64) {
+	return Decompose(IDFromUUID(uuid))
+}
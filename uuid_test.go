@@ -0,0 +1,36 @@
+package snowflake
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestUUIDRoundTrip(t *testing.T) {
+	clock := newManualClock(int64(epoch + 1000))
+	g := newTestGenerator(t, clock)
+	var prev [16]byte
+	for i := 0; i < 100; i++ {
+		if i%10 == 0 {
+			clock.Add(1)
+		}
+		uuid, err := g.GenerateUUID()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uuid[6]>>4 != 8 || uuid[8]>>6 != 0b10 {
+			t.Fatalf("got version %d variant %b, want 8 and 10", uuid[6]>>4, uuid[8]>>6)
+		}
+		id, _ := g.Last()
+		if got := IDFromUUID(uuid); got != id {
+			t.Fatalf("IDFromUUID: got %d, want %d", got, id)
+		}
+		milli, idcID, machineID, seq := DecomposeUUID(uuid)
+		if milli != clock.NowMilli() || idcID != 1 || machineID != 1 || seq != int64(i%10) {
+			t.Fatalf("got (%d, %d, %d, %d), want (%d, 1, 1, %d)", milli, idcID, machineID, seq, clock.NowMilli(), i%10)
+		}
+		if bytes.Compare(uuid[:], prev[:]) <= 0 {
+			t.Fatalf("UUID %x not greater than previous %x", uuid, prev)
+		}
+		prev = uuid
+	}
+}